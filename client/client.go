@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"sync"
 
 	"github.com/goat-project/goat-one/resource"
@@ -14,12 +15,14 @@ import (
 type Client struct {
 }
 
-// Run reads, filters and writes Accountable.
-func (c *Client) Run(processor processor.Interface, filter filter.Interface, preparer preparer.Interface) {
+// Run reads, filters and writes Accountable. When the context is canceled, listing and retrieving stop and resources
+// which were already retrieved are written before the writing is finished.
+func (c *Client) Run(ctx context.Context, processor processor.Interface, filter filter.Interface,
+	preparer preparer.Interface) {
 	var mapWg sync.WaitGroup
 	mapWg.Add(1)
 
-	go preparer.InitializeMaps(&mapWg)
+	go preparer.InitializeMaps(ctx, &mapWg)
 
 	// initialize channels
 	read := make(chan resource.Resource)
@@ -30,9 +33,9 @@ func (c *Client) Run(processor processor.Interface, filter filter.Interface, pre
 	done := make(chan bool)
 	defer close(done)
 
-	go processor.ListResources(read)
+	go processor.ListResources(ctx, read)
 	go filter.Filter(read, filtered)
-	go processor.RetrieveInfoResource(filtered, fullInfo)
+	go processor.RetrieveInfoResource(ctx, filtered, fullInfo)
 	go preparer.Prepare(fullInfo, done, &mapWg)
 
 	<-done
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/spf13/viper"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// getWindow returns window of a given resource. For incremental accounting, the window starts
+// at the checkpoint of the last successful run of the resource, the configured window is used otherwise.
+func getWindow(resource string, recordsFrom, recordsTo time.Time) (time.Time, time.Time) {
+	if !viper.GetBool(constants.CfgIncremental) {
+		return recordsFrom, recordsTo
+	}
+
+	identifier := viper.GetString(constants.CfgIdentifier)
+
+	checkpoint, err := getStore().LoadCheckpoint(resource, identifier)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": resource, "identifier": identifier}).Fatal(
+			"error load checkpoint")
+	}
+
+	if checkpoint == nil {
+		log.WithFields(log.Fields{"resource": resource, "identifier": identifier}).Info(
+			"no checkpoint, window is given by configuration")
+		return recordsFrom, recordsTo
+	}
+
+	if !checkpoint.RecordsTo.Before(recordsTo) {
+		log.WithFields(log.Fields{
+			"resource": resource, "checkpoint": checkpoint.RecordsTo, "records-to": recordsTo,
+		}).Fatal("checkpoint is not before the end of the window")
+	}
+
+	return checkpoint.RecordsTo, recordsTo
+}
+
+// succeeded returns true when all records of all zones were written without errors, so the checkpoint can be
+// moved to the end of the window. Dry runs and runs interrupted by a signal never succeed.
+func succeeded(ctx context.Context, reps []*report.Reporter) bool {
+	return !viper.GetBool(constants.CfgDryRun) && ctx.Err() == nil && len(reps) > 0 &&
+		report.ExitCodeOf(reps...) == report.ExitOK
+}
+
+// saveCheckpoint stores the end of the window as checkpoint of given resources for incremental accounting
+// when the accounting succeeded.
+func saveCheckpoint(ctx context.Context, reps []*report.Reporter, recordsTo time.Time, resources ...string) {
+	if !viper.GetBool(constants.CfgIncremental) || !succeeded(ctx, reps) {
+		return
+	}
+
+	identifier := viper.GetString(constants.CfgIdentifier)
+
+	for _, resource := range resources {
+		if err := getStore().SaveCheckpoint(resource, identifier, recordsTo); err != nil {
+			log.WithFields(log.Fields{"error": err, "resource": resource, "identifier": identifier}).Error(
+				"error save checkpoint")
+			continue
+		}
+
+		log.WithFields(log.Fields{"resource": resource, "identifier": identifier, "records-to": recordsTo}).Info(
+			"checkpoint saved")
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/logger"
+	"github.com/goat-project/goat-one/resource/virtualmachine"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain records",
+	Long: "The explain command shows how records of a resource are derived from OpenNebula data, " +
+		"field by field with its source. Nothing is sent to a server.",
+}
+
+var explainVMCmd = &cobra.Command{
+	Use:   "vm <id>",
+	Short: "Explain virtual machine records",
+	Long: "The explain vm command retrieves a virtual machine, prepares its records as the vm command does " +
+		"with the settings of virtual machines from configuration and shows every field with its source.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Init()
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "id": args[0]}).Fatal("virtual machine ID is not a number")
+		}
+
+		loadSecret()
+		checkFlags(append(vmRequired, constants.CfgOpennebulaEndpoint, constants.CfgOpennebulaSecret,
+			constants.CfgOpennebulaTimeout))
+		if viper.GetBool("debug") {
+			log.WithFields(log.Fields{"version": version}).Debug("goat-one version")
+			logFlags(vmFlags)
+		}
+
+		readLimiter := getReadLimiter()
+
+		explainVM(id, readLimiter)
+	},
+}
+
+func initExplain() {
+	goatOneCmd.AddCommand(explainCmd)
+	explainCmd.AddCommand(explainVMCmd)
+}
+
+func explainVM(id int, readLimiter *rate.Limiter) {
+	explainer := virtualmachine.CreateExplainer(getReader(readLimiter), virtualmachine.CreateFilter())
+	if explainer == nil {
+		log.WithFields(log.Fields{}).Fatal("error create explainer")
+	}
+
+	explanations, err := explainer.Explain(signalContext(), id)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "id": id}).Fatal("error explain virtual machine")
+	}
+
+	for i, explanation := range explanations {
+		if i > 0 {
+			if _, err = os.Stdout.WriteString("\n"); err != nil {
+				log.WithFields(log.Fields{"error": err}).Fatal("error write explanation")
+			}
+		}
+
+		if err = explanation.Write(os.Stdout); err != nil {
+			log.WithFields(log.Fields{"error": err}).Fatal("error write explanation")
+		}
+	}
+}
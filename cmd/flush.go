@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/logger"
+	"github.com/goat-project/goat-one/resource/network"
+	"github.com/goat-project/goat-one/resource/storage"
+	"github.com/goat-project/goat-one/resource/virtualmachine"
+	"github.com/goat-project/goat-one/writer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var flushRequired = []string{constants.CfgEndpoint, constants.CfgSpoolDir}
+
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send spooled data",
+	Long: "The flush command sends virtual machine, network and storage records which were not delivered " +
+		"to a server in the order they were spooled and removes them from the spool once the server confirms them.",
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Init()
+
+		if viper.GetBool(constants.CfgDryRun) {
+			log.WithFields(log.Fields{}).Fatal("dry run is not supported by flush, spooled records are already rendered")
+		}
+
+		checkFlags(flushRequired)
+		if viper.GetBool("debug") {
+			log.WithFields(log.Fields{"version": version}).Debug("goat-one version")
+			logFlags(nil)
+		}
+
+		writeLimiter := getWriteLimiter()
+
+		flush(writeLimiter)
+	},
+}
+
+func initFlush() {
+	goatOneCmd.AddCommand(flushCmd)
+}
+
+func flush(writeLimiter *rate.Limiter) {
+	spool := getSpool()
+
+	conn := getConn()
+	if conn == nil {
+		log.WithFields(log.Fields{}).Fatal("unable to flush spool without connection to gRPC server")
+	}
+
+	vmFlushed := flushEntries(spool, conn, constants.ResourceVM, func() resourceWriter {
+		return virtualmachine.CreateWriter(writeLimiter)
+	})
+	networkFlushed := flushEntries(spool, conn, constants.ResourceNetwork, func() resourceWriter {
+		return network.CreateWriter(writeLimiter)
+	})
+	storageFlushed := flushEntries(spool, conn, constants.ResourceStorage, func() resourceWriter {
+		return storage.CreateWriter(writeLimiter)
+	})
+
+	if err := conn.Close(); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("error close gRPC connection")
+	}
+
+	if !vmFlushed || !networkFlushed || !storageFlushed {
+		log.WithFields(log.Fields{}).Fatal("unable to flush the whole spool")
+	}
+}
+
+// flushEntries replays spool entries of a given resource in order. It stops on the first entry
+// which was not confirmed by the server, so the order of the remaining entries is kept for the next flush.
+// Entries orphaned by a crashed run are recovered first.
+func flushEntries(spool *writer.Spool, conn *grpc.ClientConn, name string, create func() resourceWriter) bool {
+	if err := spool.Recover(name); err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": name}).Error("error recover spool entries")
+		return false
+	}
+
+	entries, err := spool.Entries(name)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": name}).Error("error list spool entries")
+		return false
+	}
+
+	for _, entry := range entries {
+		if err = spool.Replay(entry, create(), conn); err != nil {
+			log.WithFields(log.Fields{"error": err, "entry": entry}).Error("error flush spool entry")
+			return false
+		}
+
+		log.WithFields(log.Fields{"entry": entry, "resource": name}).Info("spool entry flushed")
+	}
+
+	return true
+}
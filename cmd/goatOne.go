@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
+	"os"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/onego-project/onego"
 
@@ -12,6 +14,18 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/goat-project/goat-one/logger"
+	"github.com/goat-project/goat-one/metrics"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource/network"
+	"github.com/goat-project/goat-one/resource/storage"
+	"github.com/goat-project/goat-one/resource/virtualmachine"
+	"github.com/goat-project/goat-one/secret"
+	"github.com/goat-project/goat-one/state"
+	"github.com/goat-project/goat-one/window"
+	"github.com/goat-project/goat-one/writer"
+	"github.com/goat-project/goat-one/zone"
+	"github.com/golang/protobuf/ptypes/empty"
 
 	"github.com/goat-project/goat-one/constants"
 
@@ -23,11 +37,15 @@ import (
 
 const version = "1.0.0"
 
-const requestsPerSecond = 30
-
 var goatOneFlags = []string{constants.CfgIdentifier, constants.CfgRecordsFrom, constants.CfgRecordsTo,
 	constants.CfgRecordsForPeriod, constants.CfgEndpoint, constants.CfgOpennebulaEndpoint,
-	constants.CfgOpennebulaSecret, constants.CfgOpennebulaTimeout, constants.CfgDebug, constants.CfgLogPath}
+	constants.CfgOpennebulaSecret, constants.CfgOpennebulaSecretFile, constants.CfgOpennebulaTimeout,
+	constants.CfgDebug, constants.CfgLogPath, constants.CfgOutput, constants.CfgOutputDir, constants.CfgOutputFormat,
+	constants.CfgSpoolDir, constants.CfgTLSCA, constants.CfgTLSCert, constants.CfgTLSKey, constants.CfgTLSServerName,
+	constants.CfgErrorPolicy, constants.CfgStateDir, constants.CfgIncremental, constants.CfgMetricsAddress,
+	constants.CfgMetricsPushgateway, constants.CfgDryRun, constants.CfgDryRunFormat, constants.CfgRejectFile,
+	constants.CfgReadQPS, constants.CfgReadBurst, constants.CfgWriteQPS, constants.CfgWriteBurst,
+	constants.CfgListWorkers, constants.CfgFilterWorkers, constants.CfgRetrieveWorkers, constants.CfgPrepareWorkers}
 
 var goatOneCmd = &cobra.Command{
 	Use:   "goat-one",
@@ -38,19 +56,46 @@ var goatOneCmd = &cobra.Command{
 	Version: version,
 	Run: func(cmd *cobra.Command, args []string) {
 		logger.Init()
+		serveMetrics()
 
-		checkRequired(append(vmRequired, append(networkRequired, storageRequired...)...))
+		required := append(vmRequired, append(networkRequired, storageRequired...)...)
+		checkRequired(required)
 		if viper.GetBool("debug") {
 			log.WithFields(log.Fields{"version": version}).Debug("goat-one version")
 			logFlags(append(vmFlags, append(networkFlags, storageFlags...)...))
 		}
 
-		readLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-		writeLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-
-		accountVM(readLimiter, writeLimiter) // TODO: make accounting parallel
-		accountNetwork(readLimiter, writeLimiter)
-		accountStorage(readLimiter, writeLimiter)
+		zones := getZones(required)
+		writeLimiter := getWriteLimiter()
+
+		ctx := signalContext()
+		recordsFrom, recordsTo := window.Create()
+		vmFilter := virtualmachine.CreateWindowFilter(getWindow(constants.ResourceVM, recordsFrom, recordsTo))
+		networkFilter := network.CreateWindowFilter(getWindow(constants.ResourceNetwork, recordsFrom, recordsTo))
+		storageFilter := storage.CreateWindowFilter(getWindow(constants.ResourceStorage, recordsFrom, recordsTo))
+
+		reps := accountZones(ctx, zones, func(read *reader.Reader, rep *report.Reporter) {
+			// pipelines share the reader, so OpenNebula calls share the rate limit and resources listed by more
+			// pipelines are listed only once
+			var wg sync.WaitGroup
+			for _, account := range []func(){
+				func() { accountVM(ctx, read, vmFilter, writeLimiter, rep) },
+				func() { accountNetwork(ctx, read, networkFilter, writeLimiter, rep) },
+				func() { accountStorage(ctx, read, storageFilter, writeLimiter, rep) },
+			} {
+				wg.Add(1)
+				go func(account func()) {
+					defer wg.Done()
+					account()
+				}(account)
+			}
+
+			wg.Wait()
+		})
+
+		saveCheckpoint(ctx, reps, recordsTo, constants.ResourceVM, constants.ResourceNetwork,
+			constants.ResourceStorage)
+		exit(ctx, reps)
 	},
 }
 
@@ -69,11 +114,27 @@ func Initialize() {
 	initVM()
 	initNetwork()
 	initStorage()
+	initFlush()
+	initServe()
+	initExplain()
 }
 
 func initGoatOne() {
 	cobra.OnInitialize(initConfig)
 
+	viper.SetDefault(constants.CfgOutput, constants.OutputGRPC)
+	viper.SetDefault(constants.CfgOutputFormat, constants.FormatProtobuf)
+	viper.SetDefault(constants.CfgErrorPolicy, constants.PolicyFailFast)
+	viper.SetDefault(constants.CfgDryRunFormat, constants.FormatJSON)
+	viper.SetDefault(constants.CfgReadQPS, 30)
+	viper.SetDefault(constants.CfgReadBurst, 30)
+	viper.SetDefault(constants.CfgWriteQPS, 30)
+	viper.SetDefault(constants.CfgWriteBurst, 30)
+	viper.SetDefault(constants.CfgListWorkers, 10)
+	viper.SetDefault(constants.CfgFilterWorkers, 10)
+	viper.SetDefault(constants.CfgRetrieveWorkers, 30)
+	viper.SetDefault(constants.CfgPrepareWorkers, 30)
+
 	goatOneCmd.PersistentFlags().StringP(constants.CfgIdentifier, "i", viper.GetString(constants.CfgIdentifier),
 		"goat identifier [IDENTIFIER] (required)")
 	goatOneCmd.PersistentFlags().StringP(constants.CfgRecordsFrom, "f", viper.GetString(constants.CfgRecordsFrom),
@@ -88,11 +149,62 @@ func initGoatOne() {
 		viper.GetString(constants.CfgOpennebulaEndpoint), "OpenNebula endpoint [OPENNEBULA_ENDPOINT] (required)")
 	goatOneCmd.PersistentFlags().StringP(constants.CfgOpennebulaSecret, "s",
 		viper.GetString(constants.CfgOpennebulaSecret), "OpenNebula secret [OPENNEBULA_SECRET] (required)")
+	goatOneCmd.PersistentFlags().String(constants.CfgOpennebulaSecretFile,
+		viper.GetString(constants.CfgOpennebulaSecretFile),
+		"file with OpenNebula secret in one_auth format [OPENNEBULA_SECRET_FILE]")
 	goatOneCmd.PersistentFlags().String(constants.CfgOpennebulaTimeout, viper.GetString(constants.CfgOpennebulaTimeout),
 		"timeout for OpenNebula calls [TIMEOUT_FOR_OPENNEBULA_CALLS] (required)")
 	goatOneCmd.PersistentFlags().StringP(constants.CfgDebug, "d", viper.GetString(constants.CfgDebug),
 		"debug")
 	goatOneCmd.PersistentFlags().String(constants.CfgLogPath, viper.GetString(constants.CfgLogPath), "path to log file")
+	goatOneCmd.PersistentFlags().String(constants.CfgOutput, viper.GetString(constants.CfgOutput),
+		"output the records are written to (grpc/file) [OUTPUT]")
+	goatOneCmd.PersistentFlags().String(constants.CfgOutputDir, viper.GetString(constants.CfgOutputDir),
+		"directory the records are written to for file output [OUTPUT_DIR]")
+	goatOneCmd.PersistentFlags().String(constants.CfgOutputFormat, viper.GetString(constants.CfgOutputFormat),
+		"format of the records written for file output (protobuf/json) [OUTPUT_FORMAT]")
+	goatOneCmd.PersistentFlags().String(constants.CfgSpoolDir, viper.GetString(constants.CfgSpoolDir),
+		"directory the records not delivered to goat server are spooled to [SPOOL_DIR]")
+	goatOneCmd.PersistentFlags().String(constants.CfgTLSCA, viper.GetString(constants.CfgTLSCA),
+		"CA bundle verifying goat server certificate [TLS_CA]")
+	goatOneCmd.PersistentFlags().String(constants.CfgTLSCert, viper.GetString(constants.CfgTLSCert),
+		"client certificate for mutual TLS with goat server [TLS_CERT]")
+	goatOneCmd.PersistentFlags().String(constants.CfgTLSKey, viper.GetString(constants.CfgTLSKey),
+		"client key for mutual TLS with goat server [TLS_KEY]")
+	goatOneCmd.PersistentFlags().String(constants.CfgTLSServerName, viper.GetString(constants.CfgTLSServerName),
+		"server name verified in goat server certificate [TLS_SERVER_NAME]")
+	goatOneCmd.PersistentFlags().String(constants.CfgErrorPolicy, viper.GetString(constants.CfgErrorPolicy),
+		"policy for resources which failed (fail-fast/skip-and-report/max-errors=N) [ERROR_POLICY]")
+	goatOneCmd.PersistentFlags().String(constants.CfgStateDir, viper.GetString(constants.CfgStateDir),
+		"directory state of accounting is kept in between runs [STATE_DIR]")
+	goatOneCmd.PersistentFlags().Bool(constants.CfgIncremental, viper.GetBool(constants.CfgIncremental),
+		"start the window at the end of the last successful window [INCREMENTAL]")
+	goatOneCmd.PersistentFlags().String(constants.CfgMetricsAddress, viper.GetString(constants.CfgMetricsAddress),
+		"address metrics are served on during accounting [METRICS_ADDRESS]")
+	goatOneCmd.PersistentFlags().String(constants.CfgMetricsPushgateway,
+		viper.GetString(constants.CfgMetricsPushgateway), "pushgateway metrics are pushed to [METRICS_PUSHGATEWAY]")
+	goatOneCmd.PersistentFlags().Bool(constants.CfgDryRun, viper.GetBool(constants.CfgDryRun),
+		"print the records to stdout instead of writing them to the output [DRY_RUN]")
+	goatOneCmd.PersistentFlags().String(constants.CfgDryRunFormat, viper.GetString(constants.CfgDryRunFormat),
+		"format of the records printed by dry run (json/table) [DRY_RUN_FORMAT]")
+	goatOneCmd.PersistentFlags().String(constants.CfgRejectFile, viper.GetString(constants.CfgRejectFile),
+		"file records rejected by validation are appended to [REJECT_FILE]")
+	goatOneCmd.PersistentFlags().Float64(constants.CfgReadQPS, viper.GetFloat64(constants.CfgReadQPS),
+		"OpenNebula calls per second [READ_QPS]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgReadBurst, viper.GetInt(constants.CfgReadBurst),
+		"OpenNebula calls made at once [READ_BURST]")
+	goatOneCmd.PersistentFlags().Float64(constants.CfgWriteQPS, viper.GetFloat64(constants.CfgWriteQPS),
+		"records written to goat server per second [WRITE_QPS]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgWriteBurst, viper.GetInt(constants.CfgWriteBurst),
+		"records written to goat server at once [WRITE_BURST]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgListWorkers, viper.GetInt(constants.CfgListWorkers),
+		"pages of resources listed at once [LIST_WORKERS]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgFilterWorkers, viper.GetInt(constants.CfgFilterWorkers),
+		"resources filtered at once [FILTER_WORKERS]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgRetrieveWorkers, viper.GetInt(constants.CfgRetrieveWorkers),
+		"resources with info retrieved at once [RETRIEVE_WORKERS]")
+	goatOneCmd.PersistentFlags().Int(constants.CfgPrepareWorkers, viper.GetInt(constants.CfgPrepareWorkers),
+		"resources with records prepared and written at once [PREPARE_WORKERS]")
 
 	bindFlags(*goatOneCmd, goatOneFlags)
 
@@ -114,27 +226,247 @@ func initConfig() {
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("error config file")
 	}
+
+	// settings from environment variables, e.g. GOAT_ONE_OPENNEBULA_SECRET or GOAT_ONE_VM_SITE_NAME,
+	// replace configuration settings
+	viper.SetEnvPrefix(constants.EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+}
+
+// loadSecret sets OpenNebula secret read from the secret file when the secret is not set and registers it,
+// so it is redacted in logs.
+func loadSecret() {
+	s, err := secret.OpenNebula()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error read OpenNebula secret")
+	}
+
+	if s != "" {
+		viper.Set(constants.CfgOpennebulaSecret, s)
+		secret.Register(s)
+	}
 }
 
 func getConn() *grpc.ClientConn {
-	conn, err := grpc.Dial(viper.GetString(constants.CfgEndpoint), grpc.WithInsecure())
+	creds, err := writer.Credentials()
 	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error load TLS credentials")
+	}
+
+	conn, err := grpc.Dial(viper.GetString(constants.CfgEndpoint), creds)
+	if err != nil {
+		if viper.GetString(constants.CfgSpoolDir) != "" {
+			log.WithFields(log.Fields{"error": err}).Error("error connect to gRPC server")
+			return nil
+		}
+
 		log.WithFields(log.Fields{"error": err}).Fatal("error connect to gRPC server")
 	}
 
 	return conn
 }
 
+// resourceWriter represents a resource specific writer which is able to write records to Goat server
+// and to wrap records for writing to a file.
+type resourceWriter interface {
+	SetUp(*grpc.ClientConn) error
+	Write(writer.Record) error
+	SendIdentifier() error
+	Close() (*empty.Empty, error)
+	Send(writer.Record) error
+	CreateRecord() writer.Record
+	CreateData() writer.Record
+	WrapRecord(writer.Record) writer.Record
+	WrapIdentifier() writer.Record
+}
+
+// getWriter creates writer of records of a resource with a given name. Records are validated by rules
+// from a given configuration key before writing them.
+func getWriter(w resourceWriter, name, rulesKey string) writer.Interface {
+	v, err := writer.CreateValidator(viper.GetStringSlice(rulesKey), w.CreateRecord())
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "key": rulesKey}).Fatal("error create validator")
+	}
+
+	return writer.CreateValidatingWriter(getOutputWriter(w, name), v, name, viper.GetString(constants.CfgRejectFile))
+}
+
+func getOutputWriter(w resourceWriter, name string) writer.Interface {
+	if viper.GetBool(constants.CfgDryRun) {
+		dw, err := writer.CreateDryRunWriter(name)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Fatal("error create dry run writer")
+		}
+
+		return dw
+	}
+
+	if viper.GetString(constants.CfgOutput) == constants.OutputFile {
+		fw, err := writer.CreateFileWriter(w, name, zone.Current())
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Fatal("error create file writer")
+		}
+
+		return fw
+	}
+
+	if viper.GetString(constants.CfgSpoolDir) != "" {
+		return writer.CreateSpoolWriter(w, getConn(), getSpool(), name)
+	}
+
+	return writer.CreateWriter(w, getConn())
+}
+
+func getSpool() *writer.Spool {
+	spool, err := writer.CreateSpool(viper.GetString(constants.CfgSpoolDir))
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error create spool")
+	}
+
+	return spool
+}
+
+func getStore() *state.Store {
+	store, err := state.CreateStore(viper.GetString(constants.CfgStateDir))
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error create state store")
+	}
+
+	return store
+}
+
+func getReporter() *report.Reporter {
+	rep, err := report.CreateReporter(viper.GetString(constants.CfgErrorPolicy))
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error create reporter")
+	}
+
+	return rep
+}
+
+// exit pushes metrics and exits with non-zero code when a resource was skipped in any zone or accounting
+// was interrupted by a signal.
+func exit(ctx context.Context, reps []*report.Reporter) {
+	pushMetrics()
+
+	code := report.ExitCodeOf(reps...)
+	if code == report.ExitOK && ctx.Err() != nil {
+		log.WithFields(log.Fields{"error": ctx.Err()}).Warn("accounting interrupted, listed resources written")
+		code = report.ExitInterrupted
+	}
+
+	if code != report.ExitOK {
+		os.Exit(code)
+	}
+}
+
+// serveMetrics serves metrics on /metrics during accounting when the address is set.
+func serveMetrics() {
+	address := viper.GetString(constants.CfgMetricsAddress)
+	if address == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(address, mux); err != nil {
+			log.WithFields(log.Fields{"error": err, "address": address}).Error("error serve metrics")
+		}
+	}()
+}
+
+// pushMetrics pushes metrics to pushgateway when its URL is set.
+func pushMetrics() {
+	url := viper.GetString(constants.CfgMetricsPushgateway)
+	if url == "" {
+		return
+	}
+
+	if err := metrics.Push(url, viper.GetString(constants.CfgIdentifier)); err != nil {
+		log.WithFields(log.Fields{"error": err, "pushgateway": url}).Error("error push metrics")
+	}
+}
+
 func getOpenNebulaClient() *onego.Client {
 	return onego.CreateClient(viper.GetString(constants.CfgOpennebulaEndpoint),
 		viper.GetString(constants.CfgOpennebulaSecret), &http.Client{})
 }
 
+// getReadLimiter creates rate limiter of OpenNebula calls given by configuration.
+func getReadLimiter() *rate.Limiter {
+	return getLimiter(constants.CfgReadQPS, constants.CfgReadBurst)
+}
+
+// getWriteLimiter creates rate limiter of records written to goat server given by configuration.
+func getWriteLimiter() *rate.Limiter {
+	return getLimiter(constants.CfgWriteQPS, constants.CfgWriteBurst)
+}
+
+func getLimiter(qpsCfg, burstCfg string) *rate.Limiter {
+	qps := viper.GetFloat64(qpsCfg)
+	burst := viper.GetInt(burstCfg)
+	if qps <= 0 || burst <= 0 {
+		log.WithFields(log.Fields{qpsCfg: qps, burstCfg: burst}).Fatal("rate limit is not positive")
+	}
+
+	return rate.NewLimiter(rate.Limit(qps), burst)
+}
+
+func getReader(readLimiter *rate.Limiter) *reader.Reader {
+	return reader.CreateReader(getOpenNebulaClient(), readLimiter)
+}
+
+// checkRequired checks required settings and global settings required by output. Settings set by zones are
+// checked for every zone when zones are configured.
 func checkRequired(required []string) {
-	globalRequired := []string{constants.CfgIdentifier, constants.CfgEndpoint, constants.CfgOpennebulaEndpoint,
-		constants.CfgOpennebulaSecret, constants.CfgOpennebulaTimeout}
+	loadSecret()
+
+	globalRequired := append([]string{constants.CfgIdentifier}, openNebulaRequired...)
+
+	switch output := viper.GetString(constants.CfgOutput); {
+	case viper.GetBool(constants.CfgDryRun):
+		// the records are printed to stdout, no output is required
+	case output == constants.OutputGRPC:
+		globalRequired = append(globalRequired, constants.CfgEndpoint)
+	case output == constants.OutputFile:
+		globalRequired = append(globalRequired, constants.CfgOutputDir)
+	default:
+		log.WithFields(log.Fields{"output": output}).Fatal("unknown output")
+	}
+
+	checkWorkers()
+
+	required = append(required, globalRequired...)
+	if zone.Configured() {
+		var notSetByZones []string
+		for _, req := range required {
+			if !zone.Sets(req) {
+				notSetByZones = append(notSetByZones, req)
+			}
+		}
+
+		required = notSetByZones
+	}
+
+	checkFlags(required)
+}
+
+// checkWorkers checks that numbers of workers of all pipeline stages are positive, otherwise the stages
+// would never run.
+func checkWorkers() {
+	for _, cfg := range []string{constants.CfgListWorkers, constants.CfgFilterWorkers, constants.CfgRetrieveWorkers,
+		constants.CfgPrepareWorkers} {
+		if workers := viper.GetInt(cfg); workers < 1 {
+			log.WithFields(log.Fields{cfg: workers}).Fatal("number of workers is not positive")
+		}
+	}
+}
 
-	for _, req := range append(required, globalRequired...) {
+func checkFlags(required []string) {
+	for _, req := range required {
 		if viper.GetString(req) == "" {
 			log.WithFields(log.Fields{"flag": req}).Fatal("required flag not set")
 		}
@@ -166,6 +498,7 @@ func lastString(ss []string) string {
 
 func logFlags(flags []string) {
 	for _, flag := range append(goatOneFlags, flags...) {
-		log.WithFields(log.Fields{"flag": flag, "value": viper.Get(flag)}).Debug("flag initialized")
+		log.WithFields(log.Fields{"flag": flag, "value": secret.Value(flag, viper.Get(flag))}).Debug(
+			"flag initialized")
 	}
 }
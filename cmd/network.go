@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"time"
+	"context"
 
 	"github.com/goat-project/goat-one/constants"
 
@@ -12,7 +12,9 @@ import (
 	"github.com/goat-project/goat-one/client"
 	"github.com/goat-project/goat-one/logger"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource/network"
+	"github.com/goat-project/goat-one/window"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/time/rate"
@@ -22,7 +24,8 @@ import (
 
 var networkRequired = []string{constants.CfgNetworkSiteName, constants.CfgNetworkCloudType}
 var networkFlags = []string{constants.CfgNetworkSiteName, constants.CfgNetworkCloudType,
-	constants.CfgNetworkCloudComputeService}
+	constants.CfgNetworkCloudComputeService, constants.CfgNetworkSource, constants.CfgNetworkGroupBy,
+	constants.CfgNetworkIPCount}
 
 var networkCmd = &cobra.Command{
 	Use:   "network",
@@ -32,6 +35,7 @@ var networkCmd = &cobra.Command{
 		"then sends them to a server for further processing.",
 	Run: func(cmd *cobra.Command, args []string) {
 		logger.Init()
+		serveMetrics()
 
 		checkRequired(networkRequired)
 		if viper.GetBool("debug") {
@@ -39,16 +43,29 @@ var networkCmd = &cobra.Command{
 			logFlags(networkFlags)
 		}
 
-		readLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-		writeLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-
-		accountNetwork(readLimiter, writeLimiter)
+		zones := getZones(networkRequired)
+		writeLimiter := getWriteLimiter()
+		recordsFrom, recordsTo := window.Create()
+		networkFilter := network.CreateWindowFilter(getWindow(constants.ResourceNetwork, recordsFrom, recordsTo))
+
+		ctx := signalContext()
+		reps := accountZones(ctx, zones, func(read *reader.Reader, rep *report.Reporter) {
+			accountNetwork(ctx, read, networkFilter, writeLimiter, rep)
+		})
+		saveCheckpoint(ctx, reps, recordsTo, constants.ResourceNetwork)
+		exit(ctx, reps)
 	},
 }
 
 func initNetwork() {
 	goatOneCmd.AddCommand(networkCmd)
 
+	viper.SetDefault(constants.CfgNetworkSource, constants.SourceUsers)
+	viper.SetDefault(constants.CfgNetworkGroupBy, constants.GroupByVirtualNetwork)
+	viper.SetDefault(constants.CfgNetworkIPCount, constants.IPCountHours)
+	viper.SetDefault(constants.CfgNetworkValidation, []string{"required=SiteName", "required=GlobalUserName",
+		"required=IpType"})
+
 	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkSiteName),
 		viper.GetString(constants.CfgNetworkSiteName), "site name [NETWORK_SITE_NAME] (required)")
 	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkCloudType),
@@ -56,18 +73,55 @@ func initNetwork() {
 	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkCloudComputeService),
 		viper.GetString(constants.CfgNetworkCloudComputeService),
 		"cloud compute service [NETWORK_CLOUD_COMPUTE_SERVICE]")
+	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkSource),
+		viper.GetString(constants.CfgNetworkSource), "source of network data (users/vnets/accounting) [NETWORK_SOURCE]")
+	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkGroupBy),
+		viper.GetString(constants.CfgNetworkGroupBy),
+		"grouping of IP addresses read from virtual networks (vnet/label) [NETWORK_GROUP_BY]")
+	networkCmd.PersistentFlags().String(parseFlagName(constants.CfgNetworkIPCount),
+		viper.GetString(constants.CfgNetworkIPCount),
+		"count of IP addresses read from accounting (hours/average) [NETWORK_IP_COUNT]")
 
 	bindFlags(*networkCmd, networkFlags)
 }
 
-func accountNetwork(readLimiter, writeLimiter *rate.Limiter) {
-	read := reader.CreateReader(getOpenNebulaClient(), readLimiter)
+func accountNetwork(ctx context.Context, read *reader.Reader, networkFilter *network.Filter,
+	writeLimiter *rate.Limiter, rep *report.Reporter) {
+	var proc *processor.Processor
+	switch source := viper.GetString(constants.CfgNetworkSource); source {
+	case constants.SourceUsers:
+		proc = processor.CreateProcessor(network.CreateProcessor(read, networkFilter, rep), rep,
+			constants.ResourceNetwork)
+	case constants.SourceVirtualNetworks:
+		proc = processor.CreateProcessor(network.CreateVirtualNetworkProcessor(read, rep), rep,
+			constants.ResourceNetwork)
+	case constants.SourceAccounting:
+		if err := network.CheckIPCount(viper.GetString(constants.CfgNetworkIPCount), networkFilter); err != nil {
+			log.WithFields(log.Fields{"error": err}).Fatal("wrong count of IP addresses")
+		}
+
+		proc = processor.CreateProcessor(network.CreateAccountingProcessor(read, networkFilter, rep), rep,
+			constants.ResourceNetwork)
+	default:
+		log.WithFields(log.Fields{"source": source}).Fatal("unknown source of network data")
+	}
+
+	groupBy := viper.GetString(constants.CfgNetworkGroupBy)
+	if groupBy != constants.GroupByVirtualNetwork && groupBy != constants.GroupByLabel {
+		log.WithFields(log.Fields{"group-by": groupBy}).Fatal("unknown grouping of IP addresses")
+	}
+
+	ipCount := viper.GetString(constants.CfgNetworkIPCount)
+	if ipCount != constants.IPCountAverage && ipCount != constants.IPCountHours {
+		log.WithFields(log.Fields{"ip-count": ipCount}).Fatal("unknown count of IP addresses")
+	}
 
-	prep := preparer.CreatePreparer(network.CreatePreparer(writeLimiter, getConn()))
-	filt := filter.CreateFilter(network.CreateFilter())
-	proc := processor.CreateProcessor(network.CreateProcessor(read))
+	write := getWriter(network.CreateWriter(writeLimiter), constants.ResourceNetwork,
+		constants.CfgNetworkValidation)
+	prep := preparer.CreatePreparer(network.CreatePreparer(write, rep), constants.ResourceNetwork)
+	filt := filter.CreateFilter(networkFilter, constants.ResourceNetwork)
 
 	c := client.Client{}
 
-	c.Run(proc, filt, prep)
+	c.Run(ctx, proc, filt, prep)
 }
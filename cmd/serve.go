@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/logger"
+	"github.com/goat-project/goat-one/metrics"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource/network"
+	"github.com/goat-project/goat-one/resource/storage"
+	"github.com/goat-project/goat-one/resource/virtualmachine"
+	"github.com/goat-project/goat-one/scheduler"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var serveRequired = []string{constants.CfgStateDir}
+var serveFlags = []string{constants.CfgServeAddress, constants.CfgServeVMSchedule, constants.CfgServeVMWindow,
+	constants.CfgServeNetworkSchedule, constants.CfgServeNetworkWindow, constants.CfgServeStorageSchedule,
+	constants.CfgServeStorageWindow}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Extract data on schedules",
+	Long: "The serve command runs as a daemon which extracts virtual machine, network and storage data " +
+		"on cron schedules, every resource for its own window, and exposes next and last run times.",
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Init()
+
+		required, flags := serveRequired, serveFlags
+		if viper.GetString(constants.CfgServeVMSchedule) != "" {
+			required, flags = append(required, vmRequired...), append(flags, vmFlags...)
+		}
+
+		if viper.GetString(constants.CfgServeNetworkSchedule) != "" {
+			required, flags = append(required, networkRequired...), append(flags, networkFlags...)
+		}
+
+		if viper.GetString(constants.CfgServeStorageSchedule) != "" {
+			required, flags = append(required, storageRequired...), append(flags, storageFlags...)
+		}
+
+		checkRequired(required)
+		if viper.GetBool("debug") {
+			log.WithFields(log.Fields{"version": version}).Debug("goat-one version")
+			logFlags(flags)
+		}
+
+		zones := getZones(required)
+		writeLimiter := getWriteLimiter()
+
+		serve(zones, writeLimiter)
+	},
+}
+
+func initServe() {
+	goatOneCmd.AddCommand(serveCmd)
+
+	viper.SetDefault(constants.CfgServeAddress, ":8080")
+	viper.SetDefault(constants.CfgServeVMSchedule, "@hourly")
+	viper.SetDefault(constants.CfgServeVMWindow, "1h")
+	viper.SetDefault(constants.CfgServeNetworkSchedule, "@daily")
+	viper.SetDefault(constants.CfgServeNetworkWindow, "1d")
+	viper.SetDefault(constants.CfgServeStorageSchedule, "@daily")
+	viper.SetDefault(constants.CfgServeStorageWindow, "1d")
+
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeAddress),
+		viper.GetString(constants.CfgServeAddress), "address next and last run times are exposed on [ADDRESS]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeVMSchedule),
+		viper.GetString(constants.CfgServeVMSchedule), "cron schedule of virtual machine data [VM_SCHEDULE]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeVMWindow),
+		viper.GetString(constants.CfgServeVMWindow), "window of virtual machine data [TIME PERIOD]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeNetworkSchedule),
+		viper.GetString(constants.CfgServeNetworkSchedule), "cron schedule of network data [NETWORK_SCHEDULE]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeNetworkWindow),
+		viper.GetString(constants.CfgServeNetworkWindow), "window of network data [TIME PERIOD]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeStorageSchedule),
+		viper.GetString(constants.CfgServeStorageSchedule), "cron schedule of storage data [STORAGE_SCHEDULE]")
+	serveCmd.PersistentFlags().String(parseFlagName(constants.CfgServeStorageWindow),
+		viper.GetString(constants.CfgServeStorageWindow), "window of storage data [TIME PERIOD]")
+
+	bindFlags(*serveCmd, serveFlags)
+}
+
+func serve(zones []accountedZone, writeLimiter *rate.Limiter) {
+	sch := scheduler.CreateScheduler(getStore(), viper.GetString(constants.CfgIdentifier))
+
+	addSchedule(sch, constants.ResourceVM, constants.CfgServeVMSchedule, constants.CfgServeVMWindow, zones,
+		func(ctx context.Context, read *reader.Reader, w scheduler.Window, rep *report.Reporter) {
+			accountVM(ctx, read, virtualmachine.CreateWindowFilter(w.From, w.To), writeLimiter, rep)
+		})
+	addSchedule(sch, constants.ResourceNetwork, constants.CfgServeNetworkSchedule, constants.CfgServeNetworkWindow,
+		zones, func(ctx context.Context, read *reader.Reader, w scheduler.Window, rep *report.Reporter) {
+			accountNetwork(ctx, read, network.CreateWindowFilter(w.From, w.To), writeLimiter, rep)
+		})
+	addSchedule(sch, constants.ResourceStorage, constants.CfgServeStorageSchedule, constants.CfgServeStorageWindow,
+		zones, func(ctx context.Context, read *reader.Reader, w scheduler.Window, rep *report.Reporter) {
+			// the window filter is used by both filtering and preparation, so records span the scheduled window
+			// and a window accounted again after a failed run gives records with the same IDs
+			accountStorage(ctx, read, storage.CreateWindowFilter(w.From, w.To), writeLimiter, rep)
+		})
+
+	ctx := signalContext()
+	serveStatus(ctx, sch)
+
+	sch.Run(ctx)
+}
+
+// addSchedule adds accounting of a resource to the scheduler when its schedule is set. Every run accounts all
+// the zones, every zone with its own reader and reporter, and it is successful only when it succeeded as
+// incremental accounting does, so the checkpoint moves only when all records were written without errors.
+func addSchedule(sch *scheduler.Scheduler, name, scheduleCfg, windowCfg string, zones []accountedZone,
+	account func(context.Context, *reader.Reader, scheduler.Window, *report.Reporter)) {
+	spec := viper.GetString(scheduleCfg)
+	if spec == "" {
+		log.WithFields(log.Fields{"resource": name}).Info("no schedule, resource is not accounted")
+		return
+	}
+
+	err := sch.Add(name, spec, viper.GetString(windowCfg), func(ctx context.Context, w scheduler.Window) bool {
+		reps := accountZones(ctx, zones, func(read *reader.Reader, rep *report.Reporter) {
+			account(ctx, read, w, rep)
+		})
+		pushMetrics()
+
+		return succeeded(ctx, reps)
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": name}).Fatal("error add schedule")
+	}
+}
+
+// serveStatus exposes next and last run times of every resource on /status and metrics on /metrics
+// until the context is canceled.
+func serveStatus(ctx context.Context, sch *scheduler.Scheduler) {
+	address := viper.GetString(constants.CfgServeAddress)
+	if address == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/status", sch)
+	mux.Handle("/metrics", metrics.Handler())
+
+	server := &http.Server{Addr: address, Handler: mux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithFields(log.Fields{"error": err, "address": address}).Fatal("error serve status")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error shut down status server")
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// signalContext returns context canceled on the first SIGINT or SIGTERM. Listing and retrieving of resources
+// stop when the context is canceled and resources which were already retrieved are written, streams to Goat server
+// are not canceled by the signal, so they are closed as usual. The second signal exits immediately.
+func signalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		log.WithFields(log.Fields{"signal": sig}).Warn("signal received, listing and retrieving stop " +
+			"and retrieved resources are written, send the signal again to exit immediately")
+		cancel()
+
+		sig = <-signals
+		log.WithFields(log.Fields{"signal": sig}).Fatal("signal received again, exit immediately")
+	}()
+
+	return ctx
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"time"
+	"context"
 
 	"github.com/goat-project/goat-one/constants"
 
@@ -11,7 +11,9 @@ import (
 	"github.com/goat-project/goat-one/preparer"
 	"github.com/goat-project/goat-one/processor"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource/storage"
+	"github.com/goat-project/goat-one/window"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/time/rate"
@@ -20,7 +22,7 @@ import (
 )
 
 var storageRequired = []string{}
-var storageFlags = []string{constants.CfgSite}
+var storageFlags = []string{constants.CfgSite, constants.CfgStorageSource}
 
 var storageCmd = &cobra.Command{
 	Use:   "storage",
@@ -30,6 +32,7 @@ var storageCmd = &cobra.Command{
 		"then sends them to a server for further processing.",
 	Run: func(cmd *cobra.Command, args []string) {
 		logger.Init()
+		serveMetrics()
 
 		checkRequired(storageRequired)
 		if viper.GetBool("debug") {
@@ -37,30 +40,54 @@ var storageCmd = &cobra.Command{
 			logFlags(storageFlags)
 		}
 
-		readLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-		writeLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-
-		accountStorage(readLimiter, writeLimiter)
+		zones := getZones(storageRequired)
+		writeLimiter := getWriteLimiter()
+		recordsFrom, recordsTo := window.Create()
+		storageFilter := storage.CreateWindowFilter(getWindow(constants.ResourceStorage, recordsFrom, recordsTo))
+
+		ctx := signalContext()
+		reps := accountZones(ctx, zones, func(read *reader.Reader, rep *report.Reporter) {
+			accountStorage(ctx, read, storageFilter, writeLimiter, rep)
+		})
+		saveCheckpoint(ctx, reps, recordsTo, constants.ResourceStorage)
+		exit(ctx, reps)
 	},
 }
 
 func initStorage() {
 	goatOneCmd.AddCommand(storageCmd)
 
+	viper.SetDefault(constants.CfgStorageSource, constants.SourceImages)
+	viper.SetDefault(constants.CfgStorageValidation, []string{"required=RecordID", "required=StorageSystem",
+		"ordered=StartTime,EndTime"})
+
 	storageCmd.PersistentFlags().String(parseFlagName(constants.CfgSite),
 		viper.GetString(constants.CfgSite), "site [SITE]")
+	storageCmd.PersistentFlags().String(parseFlagName(constants.CfgStorageSource),
+		viper.GetString(constants.CfgStorageSource), "source of storage data (images/datastores) [STORAGE_SOURCE]")
 
 	bindFlags(*storageCmd, storageFlags)
 }
 
-func accountStorage(readLimiter, writeLimiter *rate.Limiter) {
-	read := reader.CreateReader(getOpenNebulaClient(), readLimiter)
-
-	proc := processor.CreateProcessor(storage.CreateProcessor(read))
-	filt := filter.CreateFilter(storage.CreateFilter())
-	prep := preparer.CreatePreparer(storage.CreatePreparer(read, writeLimiter, getConn()))
+func accountStorage(ctx context.Context, read *reader.Reader, storageFilter *storage.Filter,
+	writeLimiter *rate.Limiter, rep *report.Reporter) {
+	var proc *processor.Processor
+	switch source := viper.GetString(constants.CfgStorageSource); source {
+	case constants.SourceImages:
+		proc = processor.CreateProcessor(storage.CreateProcessor(read, rep), rep, constants.ResourceStorage)
+	case constants.SourceDatastores:
+		proc = processor.CreateProcessor(storage.CreateDatastoreProcessor(read, storageFilter, rep), rep,
+			constants.ResourceStorage)
+	default:
+		log.WithFields(log.Fields{"source": source}).Fatal("unknown source of storage data")
+	}
+
+	filt := filter.CreateFilter(storageFilter, constants.ResourceStorage)
+	write := getWriter(storage.CreateWriter(writeLimiter), constants.ResourceStorage,
+		constants.CfgStorageValidation)
+	prep := preparer.CreatePreparer(storage.CreatePreparer(read, write, storageFilter, rep), constants.ResourceStorage)
 
 	c := client.Client{}
 
-	c.Run(proc, filt, prep)
+	c.Run(ctx, proc, filt, prep)
 }
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"time"
+	"context"
 
 	"github.com/goat-project/goat-one/filter"
 
@@ -11,7 +11,9 @@ import (
 	"github.com/goat-project/goat-one/preparer"
 	"github.com/goat-project/goat-one/processor"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource/virtualmachine"
+	"github.com/goat-project/goat-one/window"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/time/rate"
@@ -20,7 +22,9 @@ import (
 )
 
 var vmRequired = []string{constants.CfgSiteName, constants.CfgCloudType}
-var vmFlags = []string{constants.CfgSiteName, constants.CfgCloudType, constants.CfgCloudComputeService}
+var vmFlags = []string{constants.CfgSiteName, constants.CfgCloudType, constants.CfgCloudComputeService,
+	constants.CfgSource, constants.CfgClipToWindow, constants.CfgRecordPerHistory,
+	constants.CfgCPUDuration}
 
 var vmCmd = &cobra.Command{
 	Use:   "vm",
@@ -30,6 +34,7 @@ var vmCmd = &cobra.Command{
 		"then sends them to a server for further processing.",
 	Run: func(cmd *cobra.Command, args []string) {
 		logger.Init()
+		serveMetrics()
 
 		checkRequired(vmRequired)
 		if viper.GetBool("debug") {
@@ -37,34 +42,73 @@ var vmCmd = &cobra.Command{
 			logFlags(vmFlags)
 		}
 
-		readLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-		writeLimiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(requestsPerSecond)), requestsPerSecond)
-
-		accountVM(readLimiter, writeLimiter)
+		zones := getZones(vmRequired)
+		writeLimiter := getWriteLimiter()
+
+		ctx := signalContext()
+		recordsFrom, recordsTo := window.Create()
+		vmFilter := virtualmachine.CreateWindowFilter(getWindow(constants.ResourceVM, recordsFrom, recordsTo))
+		reps := accountZones(ctx, zones, func(read *reader.Reader, rep *report.Reporter) {
+			accountVM(ctx, read, vmFilter, writeLimiter, rep)
+		})
+		saveCheckpoint(ctx, reps, recordsTo, constants.ResourceVM)
+		exit(ctx, reps)
 	},
 }
 
 func initVM() {
 	goatOneCmd.AddCommand(vmCmd)
 
+	viper.SetDefault(constants.CfgSource, constants.SourcePool)
+	viper.SetDefault(constants.CfgCPUDuration, constants.CPUDurationWall)
+	viper.SetDefault(constants.CfgVMValidation, []string{"required=VmUuid", "required=SiteName",
+		"required=MachineName", "required=GlobalUserName", "required=CpuCount", "non-negative=WallDuration",
+		"non-negative=SuspendDuration", "non-negative=CpuDuration", "ordered=StartTime,EndTime"})
+
 	vmCmd.PersistentFlags().String(parseFlagName(constants.CfgSiteName), viper.GetString(constants.CfgSiteName),
 		"site name [VM_SITE_NAME] (required)")
 	vmCmd.PersistentFlags().String(parseFlagName(constants.CfgCloudType), viper.GetString(constants.CfgCloudType),
 		"cloud type [VM_CLOUD_TYPE] (required)")
 	vmCmd.PersistentFlags().String(parseFlagName(constants.CfgCloudComputeService),
 		viper.GetString(constants.CfgCloudComputeService), "cloud compute service [VM_CLOUD_COMPUTE_SERVICE]")
+	vmCmd.PersistentFlags().String(parseFlagName(constants.CfgSource), viper.GetString(constants.CfgSource),
+		"source of virtual machine data (pool/accounting) [VM_SOURCE]")
+	vmCmd.PersistentFlags().Bool(parseFlagName(constants.CfgClipToWindow), viper.GetBool(constants.CfgClipToWindow),
+		"clip usage of virtual machines to the filter window [VM_CLIP_TO_WINDOW]")
+	vmCmd.PersistentFlags().Bool(parseFlagName(constants.CfgRecordPerHistory),
+		viper.GetBool(constants.CfgRecordPerHistory),
+		"prepare record for every host a virtual machine was running on [VM_RECORD_PER_HISTORY]")
+	vmCmd.PersistentFlags().String(parseFlagName(constants.CfgCPUDuration), viper.GetString(constants.CfgCPUDuration),
+		"strategy of CPU duration (wall/wall*vcpu/monitored) [VM_CPU_DURATION]")
 
 	bindFlags(*vmCmd, vmFlags)
 }
 
-func accountVM(readLimiter, writeLimiter *rate.Limiter) {
-	read := reader.CreateReader(getOpenNebulaClient(), readLimiter)
-
-	proc := processor.CreateProcessor(virtualmachine.CreateProcessor(read))
-	filt := filter.CreateFilter(virtualmachine.CreateFilter())
-	prep := preparer.CreatePreparer(virtualmachine.CreatePreparer(read, writeLimiter, getConn()))
+func accountVM(ctx context.Context, read *reader.Reader, vmFilter *virtualmachine.Filter, writeLimiter *rate.Limiter,
+	rep *report.Reporter) {
+	var proc *processor.Processor
+	switch source := viper.GetString(constants.CfgSource); source {
+	case constants.SourcePool:
+		proc = processor.CreateProcessor(virtualmachine.CreateProcessor(read, rep), rep, constants.ResourceVM)
+	case constants.SourceAccounting:
+		proc = processor.CreateProcessor(virtualmachine.CreateAccountingProcessor(read, vmFilter, rep), rep,
+			constants.ResourceVM)
+	default:
+		log.WithFields(log.Fields{"source": source}).Fatal("unknown source of virtual machine data")
+	}
+
+	cpuDuration := viper.GetString(constants.CfgCPUDuration)
+	if cpuDuration != constants.CPUDurationWall && cpuDuration != constants.CPUDurationWallVCPU &&
+		cpuDuration != constants.CPUDurationMonitored {
+		log.WithFields(log.Fields{"cpu-duration": cpuDuration}).Fatal("unknown strategy of CPU duration")
+	}
+
+	filt := filter.CreateFilter(vmFilter, constants.ResourceVM)
+	write := getWriter(virtualmachine.CreateWriter(writeLimiter), constants.ResourceVM,
+		constants.CfgVMValidation)
+	prep := preparer.CreatePreparer(virtualmachine.CreatePreparer(read, write, vmFilter, rep), constants.ResourceVM)
 
 	c := client.Client{}
 
-	c.Run(proc, filt, prep)
+	c.Run(ctx, proc, filt, prep)
 }
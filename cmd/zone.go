@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/zone"
+	"golang.org/x/time/rate"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var openNebulaRequired = []string{constants.CfgOpennebulaEndpoint, constants.CfgOpennebulaSecret,
+	constants.CfgOpennebulaTimeout}
+
+// accountedZone represents a zone with rate limit shared by all OpenNebula calls to the zone.
+type accountedZone struct {
+	*zone.Zone
+	readLimiter *rate.Limiter
+}
+
+// getZones returns zones accounted in one run and checks required settings of every zone.
+func getZones(required []string) []accountedZone {
+	zones, err := zone.List()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error read zones")
+	}
+
+	accounted := make([]accountedZone, 0, len(zones))
+	for _, z := range zones {
+		if err := z.Check(append(required, openNebulaRequired...)); err != nil {
+			log.WithFields(log.Fields{"error": err}).Fatal("required zone setting not set")
+		}
+
+		accounted = append(accounted, accountedZone{
+			Zone:        z,
+			readLimiter: getReadLimiter(),
+		})
+	}
+
+	return accounted
+}
+
+// accountZones accounts zones one after another by a given function. Every zone has its own reader and
+// reporter, so errors in a zone do not stop accounting of other zones. It returns reporters of accounted zones.
+func accountZones(ctx context.Context, zones []accountedZone,
+	account func(*reader.Reader, *report.Reporter)) []*report.Reporter {
+	reps := make([]*report.Reporter, 0, len(zones))
+	for _, z := range zones {
+		if ctx.Err() != nil {
+			break
+		}
+
+		rep := getReporter()
+		z.Run(func() {
+			if z.Name != "" {
+				log.WithFields(log.Fields{"zone": z.Name}).Info("zone accounting started")
+			}
+
+			account(getReader(z.readLimiter), rep)
+		})
+
+		if z.Name != "" {
+			log.WithFields(log.Fields{"zone": z.Name}).Info("zone accounting finished")
+		}
+
+		rep.Summary()
+		reps = append(reps, rep)
+	}
+
+	return reps
+}
@@ -2,9 +2,10 @@ package constants
 
 // constants with error messages
 const (
-	ErrCreatePrepReaderNil  = "error create Preparer when reader is nil"
-	ErrCreatePrepLimiterNil = "error create Preparer when limiter is nil"
-	ErrCreatePrepConnNil    = "error create Preparer when gRPC client connection is nil"
+	ErrCreatePrepReaderNil   = "error create Preparer when reader is nil"
+	ErrCreatePrepWriterNil   = "error create Preparer when writer is nil"
+	ErrCreatePrepFilterNil   = "error create Preparer when filter is nil"
+	ErrCreatePrepReporterNil = "error create Preparer when reporter is nil"
 
 	ErrPrepEmptyNetUser = "error prepare empty NetUser"
 	ErrPrepNoNetUser    = "error get id, unable to prepare network record"
@@ -31,5 +32,7 @@ const (
 	ErrNoCloudType = "no cloud type in configuration"
 	ErrNoGroupName = "no group name"
 
-	ErrCreateProcReaderNil = "error create Processor when Reader is nil"
+	ErrCreateProcReaderNil   = "error create Processor when Reader is nil"
+	ErrCreateProcFilterNil   = "error create Processor when Filter is nil"
+	ErrCreateProcReporterNil = "error create Processor when Reporter is nil"
 )
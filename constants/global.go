@@ -16,10 +16,78 @@ const (
 	CfgOpennebulaEndpoint = "opennebula-endpoint"
 	// CfgOpennebulaSecret represents string (username:password) of user login
 	CfgOpennebulaSecret = "opennebula-secret" // nolint: gosec
+	// CfgOpennebulaSecretFile represents path to file with OpenNebula secret, e.g. one_auth file
+	CfgOpennebulaSecretFile = "opennebula-secret-file" // nolint: gosec
 	// CfgOpennebulaTimeout represents duration (timeout) for OpenNebula calls
 	CfgOpennebulaTimeout = "opennebula-timeout"
 	// CfgDebug represents true for debug mode; false otherwise
 	CfgDebug = "debug"
 	// CfgLogPath represents path to log file
 	CfgLogPath = "log-path"
+	// CfgOutput represents type of output (grpc/file) the records are written to
+	CfgOutput = "output"
+	// CfgOutputDir represents path to directory where the records are written for file output
+	CfgOutputDir = "output-dir"
+	// CfgOutputFormat represents format (protobuf/json) of the records written for file output
+	CfgOutputFormat = "output-format"
+	// CfgSpoolDir represents path to directory where the records not delivered to goat server are spooled
+	CfgSpoolDir = "spool-dir"
+	// CfgTLSCA represents path to CA bundle verifying goat server certificate
+	CfgTLSCA = "tls-ca"
+	// CfgTLSCert represents path to client certificate for mutual TLS with goat server
+	CfgTLSCert = "tls-cert"
+	// CfgTLSKey represents path to client key for mutual TLS with goat server
+	CfgTLSKey = "tls-key"
+	// CfgTLSServerName represents server name overriding the name of goat server verified by TLS
+	CfgTLSServerName = "tls-server-name"
+	// CfgErrorPolicy represents policy (fail-fast/skip-and-report/max-errors=N) for resources which failed
+	CfgErrorPolicy = "error-policy"
+	// CfgStateDir represents path to directory where state of accounting is kept between runs
+	CfgStateDir = "state-dir"
+	// CfgIncremental represents true when the window starts at the end of the last successful window
+	CfgIncremental = "incremental"
+	// CfgMetricsAddress represents address (host:port) where metrics are served during accounting
+	CfgMetricsAddress = "metrics-address"
+	// CfgMetricsPushgateway represents URL of pushgateway where metrics are pushed after accounting
+	CfgMetricsPushgateway = "metrics-pushgateway"
+	// CfgDryRun represents true when the records are printed to stdout instead of writing them to the output
+	CfgDryRun = "dry-run"
+	// CfgDryRunFormat represents format (json/table) of the records printed by dry run
+	CfgDryRunFormat = "dry-run-format"
+	// CfgRejectFile represents path to file where records rejected by validation are appended
+	CfgRejectFile = "reject-file"
+	// CfgReadQPS represents number of OpenNebula calls per second
+	CfgReadQPS = "read-qps"
+	// CfgReadBurst represents number of OpenNebula calls which can be made at once
+	CfgReadBurst = "read-burst"
+	// CfgWriteQPS represents number of records written to goat server per second
+	CfgWriteQPS = "write-qps"
+	// CfgWriteBurst represents number of records which can be written to goat server at once
+	CfgWriteBurst = "write-burst"
+	// CfgListWorkers represents number of pages of resources listed from OpenNebula at once
+	CfgListWorkers = "list-workers"
+	// CfgFilterWorkers represents number of resources filtered at once
+	CfgFilterWorkers = "filter-workers"
+	// CfgRetrieveWorkers represents number of resources with info retrieved from OpenNebula at once
+	CfgRetrieveWorkers = "retrieve-workers"
+	// CfgPrepareWorkers represents number of resources with records prepared and written at once
+	CfgPrepareWorkers = "prepare-workers"
+)
+
+// environment variables
+const (
+	// EnvPrefix represents prefix of environment variables with settings, e.g. GOAT_ONE_OPENNEBULA_SECRET
+	EnvPrefix = "GOAT_ONE"
+	// EnvOneAuth represents path to OpenNebula one_auth file used when no OpenNebula secret is set
+	EnvOneAuth = "ONE_AUTH"
+)
+
+// error policies
+const (
+	// PolicyFailFast represents stopping of accounting on the first error
+	PolicyFailFast = "fail-fast"
+	// PolicySkipAndReport represents skipping of resources which failed and reporting them at the end
+	PolicySkipAndReport = "skip-and-report"
+	// PolicyMaxErrors represents skipping of resources which failed until number of errors exceeds the maximum
+	PolicyMaxErrors = "max-errors"
 )
@@ -11,4 +11,42 @@ const (
 	CfgNetworkCloudType = cfgNetworkPrefix + "cloud-type"
 	// CfgNetworkCloudComputeService represents string of network cloud compute service
 	CfgNetworkCloudComputeService = cfgNetworkPrefix + "cloud-compute-service"
+	// CfgNetworkValidation represents rules validating network records before writing them
+	CfgNetworkValidation = cfgNetworkPrefix + "validation"
+	// CfgNetworkSource represents source (users/vnets/accounting) of network data
+	CfgNetworkSource = cfgNetworkPrefix + "source"
+	// CfgNetworkIPCount represents count (average/hours) of IP addresses read from accounting
+	CfgNetworkIPCount = cfgNetworkPrefix + "ip-count"
+	// CfgNetworkGroupBy represents grouping (vnet/label) of IP addresses read from virtual networks
+	CfgNetworkGroupBy = cfgNetworkPrefix + "group-by"
 )
+
+// sources of network data
+const (
+	// SourceUsers represents listing of users and their active virtual machines, IP addresses are counted per user
+	SourceUsers = "users"
+	// SourceVirtualNetworks represents listing of virtual networks with leases of their address ranges, IP addresses
+	// are counted per user and virtual network
+	SourceVirtualNetworks = "vnets"
+)
+
+// counts of IP addresses read from OpenNebula accounting, which is a source of network data as well
+// (see SourceAccounting)
+const (
+	// IPCountAverage represents average number of IP addresses held in the window
+	IPCountAverage = "average"
+	// IPCountHours represents number of hours IP addresses were held in the window, i.e. IP-hours
+	IPCountHours = "hours"
+)
+
+// groupings of IP addresses read from virtual networks
+const (
+	// GroupByVirtualNetwork represents counting of IP addresses per virtual network
+	GroupByVirtualNetwork = "vnet"
+	// GroupByLabel represents counting of IP addresses per label of address range, address ranges without
+	// label are counted per virtual network
+	GroupByLabel = "label"
+)
+
+// ResourceNetwork represents name of network resource
+const ResourceNetwork = "network"
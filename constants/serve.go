@@ -0,0 +1,22 @@
+package constants
+
+// prefix for serve subcommand
+const cfgServePrefix = "serve."
+
+// constants for serve subcommand
+const (
+	// CfgServeAddress represents address (host:port) where next and last run times are exposed
+	CfgServeAddress = cfgServePrefix + "address"
+	// CfgServeVMSchedule represents cron schedule of virtual machine accounting
+	CfgServeVMSchedule = cfgServePrefix + "vm-schedule"
+	// CfgServeVMWindow represents period of the window virtual machines are accounted for
+	CfgServeVMWindow = cfgServePrefix + "vm-window"
+	// CfgServeNetworkSchedule represents cron schedule of network accounting
+	CfgServeNetworkSchedule = cfgServePrefix + "network-schedule"
+	// CfgServeNetworkWindow represents period of the window networks are accounted for
+	CfgServeNetworkWindow = cfgServePrefix + "network-window"
+	// CfgServeStorageSchedule represents cron schedule of storage accounting
+	CfgServeStorageSchedule = cfgServePrefix + "storage-schedule"
+	// CfgServeStorageWindow represents period of the window storages are accounted for
+	CfgServeStorageWindow = cfgServePrefix + "storage-window"
+)
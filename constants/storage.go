@@ -7,4 +7,30 @@ const cfgStoragePrefix = "storage."
 const (
 	// CfgSite represents string of storage site
 	CfgSite = cfgStoragePrefix + "site"
+	// CfgStorageValidation represents rules validating storage records before writing them
+	CfgStorageValidation = cfgStoragePrefix + "validation"
+	// CfgStorageSource represents source (images/datastores) of storage data
+	CfgStorageSource = cfgStoragePrefix + "source"
 )
+
+// sources of storage data
+const (
+	// SourceImages represents listing of images, every image is accounted
+	SourceImages = "images"
+	// SourceDatastores represents listing of datastores and images, capacity of every datastore and capacity used
+	// by images of every user and group in the datastore are accounted
+	SourceDatastores = "datastores"
+)
+
+// storage classes of virtual machine disks not accounted as images
+const (
+	// StorageClassVolatile represents volatile disk of a virtual machine
+	StorageClassVolatile = "volatile"
+	// StorageClassClone represents clone of a non-persistent image used by a virtual machine
+	StorageClassClone = "clone"
+	// StorageClassSnapshot represents snapshot of a disk of a virtual machine
+	StorageClassSnapshot = "snapshot"
+)
+
+// ResourceStorage represents name of storage resource
+const ResourceStorage = "storage"
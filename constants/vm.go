@@ -11,4 +11,35 @@ const (
 	CfgCloudType = cfgVMPrefix + "cloud-type"
 	// CfgCloudComputeService represents string of virtual machine cloud compute service
 	CfgCloudComputeService = cfgVMPrefix + "cloud-compute-service"
+	// CfgSource represents source (pool/accounting) of virtual machine data
+	CfgSource = cfgVMPrefix + "source"
+	// CfgClipToWindow represents true when usage of virtual machines is clipped to the filter window
+	CfgClipToWindow = cfgVMPrefix + "clip-to-window"
+	// CfgRecordPerHistory represents true when a record is prepared for every history record of virtual machine
+	CfgRecordPerHistory = cfgVMPrefix + "record-per-history"
+	// CfgCPUDuration represents strategy (wall/wall*vcpu/monitored) of virtual machine CPU duration
+	CfgCPUDuration = cfgVMPrefix + "cpu-duration"
+	// CfgVMValidation represents rules validating virtual machine records before writing them
+	CfgVMValidation = cfgVMPrefix + "validation"
 )
+
+// sources of virtual machine data
+const (
+	// SourcePool represents listing of virtual machine pool and retrieving info for every virtual machine
+	SourcePool = "pool"
+	// SourceAccounting represents OpenNebula accounting of virtual machines for the filter window
+	SourceAccounting = "accounting"
+)
+
+// strategies of virtual machine CPU duration
+const (
+	// CPUDurationWall represents CPU duration equal to wall duration
+	CPUDurationWall = "wall"
+	// CPUDurationWallVCPU represents CPU duration equal to wall duration multiplied by count of virtual CPUs
+	CPUDurationWallVCPU = "wall*vcpu"
+	// CPUDurationMonitored represents CPU duration computed from CPU usage in monitoring records
+	CPUDurationMonitored = "monitored"
+)
+
+// ResourceVM represents name of virtual machine resource
+const ResourceVM = "vm"
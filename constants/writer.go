@@ -0,0 +1,33 @@
+package constants
+
+// outputs the records are written to
+const (
+	// OutputGRPC represents output to Goat server via gRPC
+	OutputGRPC = "grpc"
+	// OutputFile represents output to local files
+	OutputFile = "file"
+)
+
+// formats of the records written for file output
+const (
+	// FormatProtobuf represents length-delimited protobuf messages
+	FormatProtobuf = "protobuf"
+	// FormatJSON represents JSON Lines
+	FormatJSON = "json"
+)
+
+// formats of the records printed by dry run
+const (
+	// FormatTable represents table with a column for every field of the record
+	FormatTable = "table"
+)
+
+// rules validating fields of the records before writing them
+const (
+	// RuleRequired represents field which is set and not empty
+	RuleRequired = "required"
+	// RuleNonNegative represents number or duration field which is not negative
+	RuleNonNegative = "non-negative"
+	// RuleOrdered represents two timestamp fields where the first one is not after the second one
+	RuleOrdered = "ordered"
+)
@@ -0,0 +1,4 @@
+package constants
+
+// CfgZones represents list of OpenNebula zones accounted in one run, every zone with its own settings
+const CfgZones = "zones"
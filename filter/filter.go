@@ -3,35 +3,69 @@ package filter
 import (
 	"sync"
 
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/goat-project/goat-one/resource"
+	"github.com/remeh/sizedwaitgroup"
+	"github.com/spf13/viper"
 )
 
 // Filter to filter resource data.
 type Filter struct {
 	filterI filterI
+	name    string
+	workers int
 }
 
 type filterI interface {
 	Filtering(res resource.Resource, filtered chan resource.Resource, wg *sync.WaitGroup)
 }
 
-// CreateFilter creates Filter.
-func CreateFilter(filterI filterI) *Filter {
+// CreateFilter creates Filter for resources with a given name. Number of resources filtered at once is given
+// by configuration.
+func CreateFilter(filterI filterI, name string) *Filter {
 	return &Filter{
 		filterI: filterI,
+		name:    name,
+		workers: viper.GetInt(constants.CfgFilterWorkers),
 	}
 }
 
 // Filter reads resources from read channel, filter them according to configuration or command line flags
-// and write them to filtered channel.
+// and write them to filtered channel. Listed resources and resources which are filtered out are counted.
 func (f *Filter) Filter(read, filtered chan resource.Resource) {
 	var wg sync.WaitGroup
+	workers := sizedwaitgroup.New(f.workers)
 
+	passed := make(chan resource.Resource)
+	passedCount := make(chan int)
+
+	go func() {
+		count := 0
+		for data := range passed {
+			filtered <- data
+			count++
+		}
+
+		passedCount <- count
+	}()
+
+	listed := 0
 	for data := range read {
+		listed++
+		metrics.Count(f.name, metrics.StageListed)
+
 		wg.Add(1)
-		go f.filterI.Filtering(data, filtered, &wg)
+		workers.Add()
+		go func(data resource.Resource) {
+			defer workers.Done()
+			f.filterI.Filtering(data, passed, &wg)
+		}(data)
 	}
 
 	wg.Wait()
+	close(passed)
+
+	metrics.Add(f.name, metrics.StageFilteredOut, listed-<-passedCount)
 	close(filtered)
 }
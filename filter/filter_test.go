@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+type testResource int
+
+func (r testResource) ID() (int, error) {
+	return int(r), nil
+}
+
+func (r testResource) Attribute(path string) (string, error) {
+	return "", nil
+}
+
+// countingFilter passes resources with even ID and counts resources filtered at once.
+type countingFilter struct {
+	mutex   sync.Mutex
+	current int
+	max     int
+}
+
+func (cf *countingFilter) Filtering(res resource.Resource, filtered chan resource.Resource, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	cf.mutex.Lock()
+	cf.current++
+	if cf.current > cf.max {
+		cf.max = cf.current
+	}
+	cf.mutex.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	cf.mutex.Lock()
+	cf.current--
+	cf.mutex.Unlock()
+
+	if id, _ := res.ID(); id%2 == 0 {
+		filtered <- res
+	}
+}
+
+var _ = ginkgo.Describe("Filter tests", func() {
+	ginkgo.AfterEach(func() {
+		viper.Set(constants.CfgFilterWorkers, nil)
+	})
+
+	ginkgo.Describe("filter resources", func() {
+		ginkgo.It("should filter all resources with given number of workers", func() {
+			viper.Set(constants.CfgFilterWorkers, 3)
+
+			cf := &countingFilter{}
+			f := CreateFilter(cf, "test")
+
+			read := make(chan resource.Resource)
+			filtered := make(chan resource.Resource)
+
+			go func() {
+				for i := 0; i < 100; i++ {
+					read <- testResource(i)
+				}
+				close(read)
+			}()
+
+			go f.Filter(read, filtered)
+
+			passed := 0
+			for range filtered {
+				passed++
+			}
+
+			gomega.Expect(passed).To(gomega.Equal(50))
+			gomega.Expect(cf.max).To(gomega.BeNumerically("<=", 3))
+			gomega.Expect(cf.max).To(gomega.BeNumerically(">", 1))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package initialize
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/goat-project/goat-one/resource"
@@ -18,8 +19,8 @@ type benchmark struct {
 }
 
 // UserTemplateIdentity returns map of user ID and value in TEMPLATE/IDENTITY.
-func UserTemplateIdentity(r reader.Reader) map[int]string {
-	objs, err := r.ListAllUsers()
+func UserTemplateIdentity(ctx context.Context, r reader.Reader) map[int]string {
+	objs, err := r.ListAllUsers(ctx)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("error list all users")
 		return nil
@@ -35,8 +36,8 @@ func UserTemplateIdentity(r reader.Reader) map[int]string {
 
 // ImageTemplateCloudkeeperApplianceMpuri returns map of image ID and
 // value in TEMPLATE/CLOUDKEEPER_APPLIANCE_MPURI.
-func ImageTemplateCloudkeeperApplianceMpuri(r reader.Reader) map[int]string {
-	objs, err := r.ListAllImages()
+func ImageTemplateCloudkeeperApplianceMpuri(ctx context.Context, r reader.Reader) map[int]string {
+	objs, err := r.ListAllImages(ctx)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("error list all images")
 		return nil
@@ -52,14 +53,14 @@ func ImageTemplateCloudkeeperApplianceMpuri(r reader.Reader) map[int]string {
 
 // HostTemplateBenchmark returns two maps: map of host ID and value in TEMPLATE/BENCHMARK_TYPE and
 // map of host ID and value in TEMPLATE/BENCHMARK_VALUE.
-func HostTemplateBenchmark(r reader.Reader) (map[int]string, map[int]string) {
-	hosts, err := r.ListAllHosts()
+func HostTemplateBenchmark(ctx context.Context, r reader.Reader) (map[int]string, map[int]string) {
+	hosts, err := r.ListAllHosts(ctx)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("error list all hosts")
 		return nil, nil
 	}
 
-	clustersMap := clustersMap(r)
+	clustersMap := clustersMap(ctx, r)
 
 	hostLength := len(hosts)
 	hostTemplateBenchmarkType := make(map[int]string, hostLength)
@@ -131,10 +132,11 @@ func typeFromCluster(clustersMap map[int]benchmark, host *resources.Host) string
 	return clustersMap[clusterID].bType
 }
 
-func clustersMap(r reader.Reader) map[int]benchmark {
-	clusters, err := r.ListAllClusters()
+func clustersMap(ctx context.Context, r reader.Reader) map[int]benchmark {
+	clusters, err := r.ListAllClusters(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error list all clusters")
+		log.WithFields(log.Fields{"error": err}).Error("error list all clusters, benchmarks of clusters are not used")
+		return map[int]benchmark{}
 	}
 
 	idToBenchmark := make(map[int]benchmark, len(clusters))
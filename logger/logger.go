@@ -2,21 +2,35 @@ package logger
 
 import (
 	"os"
+	"sync"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/secret"
 	"github.com/spf13/viper"
 
 	"github.com/sirupsen/logrus"
 )
 
-// Init initializes logrus by configuration.
+var hookOnce sync.Once
+
+// Init initializes logrus by configuration. Registered secrets are redacted in all logs.
 func Init() {
+	hookOnce.Do(func() {
+		logrus.AddHook(secret.Hook{})
+	})
+
 	path := viper.GetString(constants.CfgLogPath)
 	switch path {
 	case "":
-		if viper.GetBool(constants.CfgDebug) {
+		// the records are printed to stdout by dry run
+		switch {
+		case viper.GetBool(constants.CfgDryRun) && viper.GetBool(constants.CfgDebug):
+			InitLogToStderrDebug()
+		case viper.GetBool(constants.CfgDryRun):
+			InitLogToStderr()
+		case viper.GetBool(constants.CfgDebug):
 			InitLogToStdoutDebug()
-		} else {
+		default:
 			InitLogToStdout()
 		}
 	default:
@@ -43,6 +57,18 @@ func InitLogToStdout() {
 	logrus.SetOutput(os.Stdout)
 }
 
+// InitLogToStderr inits logrus to log the info severity or above to Stderr.
+func InitLogToStderr() {
+	logrus.SetFormatter(&logrus.TextFormatter{})
+	logrus.SetOutput(os.Stderr)
+}
+
+// InitLogToStderrDebug inits logrus to log the debug severity or above to Stderr.
+func InitLogToStderrDebug() {
+	InitLogToStderr()
+	logrus.SetLevel(logrus.DebugLevel)
+}
+
 // InitLogToFile inits logrus to log the info severity or above to the file.
 func InitLogToFile(logPath string) {
 	logrus.SetFormatter(&logrus.TextFormatter{})
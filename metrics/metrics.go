@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// stages of resources in accounting
+const (
+	// StageListed represents resources listed from OpenNebula
+	StageListed = "listed"
+	// StageFilteredOut represents resources which are out of the filter window
+	StageFilteredOut = "filtered_out"
+	// StageRetrieved represents resources with info retrieved from OpenNebula
+	StageRetrieved = "retrieved"
+	// StagePrepared represents records prepared for writing
+	StagePrepared = "prepared"
+	// StageWritten represents records written to the output
+	StageWritten = "written"
+	// StageRejected represents records rejected by validation
+	StageRejected = "rejected"
+	// StageFailed represents resources and records which failed
+	StageFailed = "failed"
+)
+
+const namespace = "goat_one"
+
+// Registry contains all metrics of goat-one.
+var Registry = prometheus.NewRegistry()
+
+var (
+	resources = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resources_total",
+		Help:      "Number of resources and records by resource type and stage of accounting.",
+	}, []string{"resource", "stage"})
+
+	openNebulaCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "opennebula_call_duration_seconds",
+		Help:      "Latency of OpenNebula calls.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"call"})
+
+	openNebulaRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "opennebula_retries_total",
+		Help:      "Number of retried OpenNebula calls.",
+	}, []string{"call"})
+
+	rateLimiterWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rate_limiter_wait_seconds",
+		Help:      "Time OpenNebula calls waited for the rate limiter.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+	})
+
+	grpcSendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_send_duration_seconds",
+		Help:      "Latency of sending records to Goat server by resource type.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"resource"})
+)
+
+func init() {
+	Registry.MustRegister(resources, openNebulaCallDuration, openNebulaRetries, rateLimiterWait, grpcSendDuration)
+}
+
+// Count counts a resource of a given type in a stage of accounting.
+func Count(resource, stage string) {
+	resources.WithLabelValues(resource, stage).Inc()
+}
+
+// Add counts given number of resources of a given type in a stage of accounting.
+func Add(resource, stage string, count int) {
+	resources.WithLabelValues(resource, stage).Add(float64(count))
+}
+
+// ObserveCall observes latency of an OpenNebula call started at a given time.
+func ObserveCall(call string, start time.Time) {
+	openNebulaCallDuration.WithLabelValues(call).Observe(time.Since(start).Seconds())
+}
+
+// CountRetry counts a retry of an OpenNebula call.
+func CountRetry(call string) {
+	openNebulaRetries.WithLabelValues(call).Inc()
+}
+
+// ObserveWait observes time waited for the rate limiter from a given time.
+func ObserveWait(start time.Time) {
+	rateLimiterWait.Observe(time.Since(start).Seconds())
+}
+
+// ObserveSend observes latency of sending a record of a given type started at a given time.
+func ObserveSend(resource string, start time.Time) {
+	grpcSendDuration.WithLabelValues(resource).Observe(time.Since(start).Seconds())
+}
+
+// Handler returns handler serving all metrics of goat-one.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Push pushes all metrics of goat-one to a pushgateway with a given URL. The metrics are grouped by instance
+// identifier, so metrics of other instances are kept.
+func Push(url, identifier string) error {
+	return push.New(url, "goat-one").Gatherer(Registry).Grouping("identifier", identifier).Push()
+}
@@ -0,0 +1,99 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goat-project/goat-one/metrics"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// pushgateway is a local stand-in for pushgateway which keeps the last pushed request.
+type pushgateway struct {
+	method string
+	path   string
+	body   string
+}
+
+func (p *pushgateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	p.method, p.path, p.body = r.Method, r.URL.Path, string(body)
+	w.WriteHeader(http.StatusOK)
+}
+
+func scrape() string {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	return recorder.Body.String()
+}
+
+var _ = ginkgo.Describe("Metrics tests", func() {
+	ginkgo.Describe("serve metrics", func() {
+		ginkgo.Context("when resources are counted", func() {
+			ginkgo.It("should serve them by resource type and stage", func() {
+				metrics.Count("test-vm", metrics.StageListed)
+				metrics.Count("test-vm", metrics.StageListed)
+				metrics.Add("test-vm", metrics.StageFilteredOut, 1)
+
+				body := scrape()
+
+				gomega.Expect(body).To(gomega.ContainSubstring(
+					`goat_one_resources_total{resource="test-vm",stage="listed"} 2`))
+				gomega.Expect(body).To(gomega.ContainSubstring(
+					`goat_one_resources_total{resource="test-vm",stage="filtered_out"} 1`))
+			})
+		})
+
+		ginkgo.Context("when latencies are observed", func() {
+			ginkgo.It("should serve histograms", func() {
+				start := time.Now()
+				metrics.ObserveCall("one.vm.info", start)
+				metrics.CountRetry("one.vm.info")
+				metrics.ObserveWait(start)
+				metrics.ObserveSend("test-vm", start)
+
+				body := scrape()
+
+				gomega.Expect(body).To(gomega.ContainSubstring(
+					`goat_one_opennebula_call_duration_seconds_count{call="one.vm.info"} 1`))
+				gomega.Expect(body).To(gomega.ContainSubstring(`goat_one_opennebula_retries_total{call="one.vm.info"} 1`))
+				gomega.Expect(body).To(gomega.ContainSubstring(`goat_one_rate_limiter_wait_seconds_count`))
+				gomega.Expect(body).To(gomega.ContainSubstring(
+					`goat_one_grpc_send_duration_seconds_count{resource="test-vm"} 1`))
+			})
+		})
+	})
+
+	ginkgo.Describe("push metrics", func() {
+		ginkgo.Context("when pushgateway is available", func() {
+			ginkgo.It("should push metrics grouped by identifier", func() {
+				gateway := &pushgateway{}
+				server := httptest.NewServer(gateway)
+				defer server.Close()
+
+				metrics.Count("test-storage", metrics.StageWritten)
+
+				gomega.Expect(metrics.Push(server.URL, "goat")).To(gomega.Succeed())
+
+				gomega.Expect(gateway.method).To(gomega.Equal(http.MethodPut))
+				gomega.Expect(gateway.path).To(gomega.Equal("/metrics/job/goat-one/identifier/goat"))
+				gomega.Expect(gateway.body).NotTo(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when pushgateway is not available", func() {
+			ginkgo.It("should return error", func() {
+				server := httptest.NewServer(http.NotFoundHandler())
+				server.Close()
+
+				gomega.Expect(metrics.Push(server.URL, "goat")).NotTo(gomega.Succeed())
+			})
+		})
+	})
+})
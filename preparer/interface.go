@@ -1,6 +1,7 @@
 package preparer
 
 import (
+	"context"
 	"sync"
 
 	"github.com/goat-project/goat-one/resource"
@@ -8,6 +9,6 @@ import (
 
 // Interface to prepare data to specific structure for writing to Goat server.
 type Interface interface {
-	InitializeMaps(*sync.WaitGroup)
+	InitializeMaps(context.Context, *sync.WaitGroup)
 	Prepare(chan resource.Resource, chan bool, *sync.WaitGroup)
 }
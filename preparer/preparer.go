@@ -1,28 +1,39 @@
 package preparer
 
 import (
+	"context"
 	"sync"
 
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/goat-project/goat-one/resource"
+	"github.com/remeh/sizedwaitgroup"
+	"github.com/spf13/viper"
+
 	log "github.com/sirupsen/logrus"
 )
 
 // Preparer to prepare data to specific structure for writing to Goat server.
 type Preparer struct {
-	prep preparerI
+	prep    preparerI
+	name    string
+	workers int
 }
 
 type preparerI interface {
 	Preparation(resource.Resource, *sync.WaitGroup)
-	InitializeMaps(*sync.WaitGroup)
+	InitializeMaps(context.Context, *sync.WaitGroup)
 	SendIdentifier() error
 	Finish()
 }
 
-// CreatePreparer creates Preparer for accountable records.
-func CreatePreparer(prep preparerI) *Preparer {
+// CreatePreparer creates Preparer for accountable records of resources with a given name. Number of resources
+// with records prepared at once is given by configuration.
+func CreatePreparer(prep preparerI, name string) *Preparer {
 	return &Preparer{
-		prep: prep,
+		prep:    prep,
+		name:    name,
+		workers: viper.GetInt(constants.CfgPrepareWorkers),
 	}
 }
 
@@ -31,6 +42,7 @@ func (p *Preparer) Prepare(fullInfo chan resource.Resource, done chan bool, mapW
 	mapWg.Wait()
 
 	var wg sync.WaitGroup
+	workers := sizedwaitgroup.New(p.workers)
 
 	identifierSend := false
 
@@ -42,15 +54,22 @@ func (p *Preparer) Prepare(fullInfo chan resource.Resource, done chan bool, mapW
 			}
 			identifierSend = true
 		}
+		metrics.Count(p.name, metrics.StageRetrieved)
+
 		wg.Add(1)
-		go p.prep.Preparation(data, &wg)
+		workers.Add()
+		go func(data resource.Resource) {
+			defer workers.Done()
+			p.prep.Preparation(data, &wg)
+		}(data)
 	}
 
 	wg.Wait()
 
 	// If the identifier was not sent, there is no resource to prepare and send,
 	// a gRPC connection was not open and no finishing and closing of a connection are needed.
-	if identifierSend {
+	// Dry run writer is finished anyway, so the summary is printed even when there are no records.
+	if identifierSend || viper.GetBool(constants.CfgDryRun) {
 		p.prep.Finish()
 	}
 
@@ -58,6 +77,6 @@ func (p *Preparer) Prepare(fullInfo chan resource.Resource, done chan bool, mapW
 }
 
 // InitializeMaps reads additional data for record.
-func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
-	p.prep.InitializeMaps(wg)
+func (p *Preparer) InitializeMaps(ctx context.Context, wg *sync.WaitGroup) {
+	p.prep.InitializeMaps(ctx, wg)
 }
@@ -1,11 +1,13 @@
 package processor
 
 import (
+	"context"
+
 	"github.com/goat-project/goat-one/resource"
 )
 
 // Interface to process Resource data.
 type Interface interface {
-	ListResources(chan resource.Resource)
-	RetrieveInfoResource(chan resource.Resource, chan resource.Resource)
+	ListResources(context.Context, chan resource.Resource)
+	RetrieveInfoResource(context.Context, chan resource.Resource, chan resource.Resource)
 }
@@ -1,58 +1,86 @@
 package processor
 
 import (
+	"context"
 	"sync"
 
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 	"github.com/onego-project/onego/errors"
 	"github.com/remeh/sizedwaitgroup"
+	"github.com/spf13/viper"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // Processor to process resource data.
 type Processor struct {
-	proc processorI
+	proc            processorI
+	reporter        *report.Reporter
+	name            string
+	listWorkers     int
+	retrieveWorkers int
 }
 
 type processorI interface {
-	Process(chan resource.Resource, chan bool, *sizedwaitgroup.SizedWaitGroup)
-	RetrieveInfo(chan resource.Resource, *sync.WaitGroup, resource.Resource)
+	Process(context.Context, chan resource.Resource, chan bool, *sizedwaitgroup.SizedWaitGroup)
+	RetrieveInfo(context.Context, chan resource.Resource, *sync.WaitGroup, resource.Resource)
 }
 
-const wgSize = 10
-
-// CreateProcessor creates Processor to manage reading from OpenNebula.
-func CreateProcessor(proc processorI) *Processor {
+// CreateProcessor creates Processor to manage reading of resources with a given name from OpenNebula
+// and reporting of skipped resources. Numbers of pages listed at once and resources with info retrieved
+// at once are given by configuration.
+func CreateProcessor(proc processorI, rep *report.Reporter, name string) *Processor {
 	return &Processor{
-		proc: proc,
+		proc:            proc,
+		reporter:        rep,
+		name:            name,
+		listWorkers:     viper.GetInt(constants.CfgListWorkers),
+		retrieveWorkers: viper.GetInt(constants.CfgRetrieveWorkers),
 	}
 }
 
-// ListResources calls method to list resource from OpenNebula.
-func (p *Processor) ListResources(read chan resource.Resource) {
-	swg := sizedwaitgroup.New(wgSize + 1)
-	readDone := make(chan bool, wgSize)
+// ListResources calls method to list resource from OpenNebula until the context is canceled.
+func (p *Processor) ListResources(ctx context.Context, read chan resource.Resource) {
+	listWorkers := p.listWorkers
+	if listWorkers < 1 {
+		listWorkers = 1
+	}
+
+	swg := sizedwaitgroup.New(listWorkers + 1)
+	readDone := make(chan bool, listWorkers)
 
 	swg.Add()
-	go p.proc.Process(read, readDone, &swg)
+	go p.proc.Process(ctx, read, readDone, &swg)
 
 	swg.Wait()
 	close(read)
 	close(readDone)
 }
 
-// RetrieveInfoResource range over filtered resource and calls method to retrieve resource info.
-func (p *Processor) RetrieveInfoResource(filtered, fullInfo chan resource.Resource) {
+// RetrieveInfoResource range over filtered resource and calls method to retrieve resource info. Info is not
+// retrieved when errors exceeded the error policy and retrieving stops when the context is canceled.
+func (p *Processor) RetrieveInfoResource(ctx context.Context, filtered, fullInfo chan resource.Resource) {
 	var wg sync.WaitGroup
+	workers := sizedwaitgroup.New(p.retrieveWorkers)
 
 	for accountable := range filtered {
 		if accountable == nil {
-			log.WithFields(log.Fields{"error": errors.ErrNoVirtualMachine}).Fatal("error retrieve resource info")
+			p.reporter.Skip(p.name, "error retrieve resource info", log.Fields{}, errors.ErrNoVirtualMachine)
+			continue
+		}
+
+		if p.reporter.Stopped() || ctx.Err() != nil {
+			continue
 		}
 
 		wg.Add(1)
-		go p.proc.RetrieveInfo(fullInfo, &wg, accountable)
+		workers.Add()
+		go func(accountable resource.Resource) {
+			defer workers.Done()
+			p.proc.RetrieveInfo(ctx, fullInfo, &wg, accountable)
+		}(accountable)
 	}
 
 	wg.Wait()
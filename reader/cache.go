@@ -0,0 +1,54 @@
+package reader
+
+import (
+	"sync"
+
+	"github.com/goat-project/goat-one/resource"
+)
+
+// cache stores resources listed by the Reader, so pipelines sharing the Reader list them only once.
+type cache struct {
+	mutex   sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	mutex  sync.Mutex
+	listed bool
+	res    []resource.Resource
+}
+
+func createCache() *cache {
+	return &cache{
+		entries: map[string]*entry{},
+	}
+}
+
+// get returns resources stored under the key or lists them when they are not stored yet. Concurrent calls
+// for the same key wait for the first one. Resources are not stored when the listing fails.
+func (c *cache) get(key string, list func() ([]resource.Resource, error)) ([]resource.Resource, error) {
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{}
+		c.entries[key] = e
+	}
+	c.mutex.Unlock()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.listed {
+		return e.res, nil
+	}
+
+	res, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	e.res = res
+	e.listed = true
+
+	return res, nil
+}
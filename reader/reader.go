@@ -2,9 +2,14 @@ package reader
 
 import (
 	"context"
+	"net/http"
 	"time"
 
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/rpc"
+
 	"github.com/goat-project/goat-one/resource"
+	networkReader "github.com/goat-project/goat-one/resource/network/reader"
 	storageReader "github.com/goat-project/goat-one/resource/storage/reader"
 	virtualMachineReader "github.com/goat-project/goat-one/resource/virtualmachine/reader"
 
@@ -13,6 +18,7 @@ import (
 	"github.com/rafaeljesus/retry-go"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/onego-project/onego/errors"
 	"github.com/spf13/viper"
 
@@ -25,8 +31,10 @@ import (
 // Reader structure to list resources and retrieve info for specific resource from OpenNebula.
 type Reader struct {
 	client      *onego.Client
+	rpcClient   *rpc.Client
 	rateLimiter *rate.Limiter
 	timeout     time.Duration
+	cache       *cache
 }
 
 type resourcesReaderI interface {
@@ -41,10 +49,17 @@ type resourcesReaderForUserI interface {
 	ReadResourcesForUser(context.Context, *onego.Client) ([]resource.Resource, error)
 }
 
+type rpcReaderI interface {
+	ReadRPC(context.Context, *rpc.Client) ([]*etree.Element, error)
+}
+
 const attempts = 3
 const sleepTime = time.Second * 1
 
-// CreateReader creates reader with onego client, rate limiter and timeout.
+// CreateReader creates reader with onego client, rate limiter and timeout. OpenNebula methods
+// which are not provided by onego are called by RPC client created from configuration. Every call
+// is canceled with the context given to the Reader method. Users, images, hosts and clusters are
+// listed once and shared by all copies of the reader.
 func CreateReader(oneClient *onego.Client, limiter *rate.Limiter) *Reader {
 	if oneClient == nil {
 		log.WithFields(log.Fields{"error": errors.ErrNoClient}).Fatal("error create Reader")
@@ -55,80 +70,112 @@ func CreateReader(oneClient *onego.Client, limiter *rate.Limiter) *Reader {
 	}).Debug("Reader created with given settings for page size, number of iterations " +
 		"for unsuccessful calls and sleep time between the calls")
 
+	rpcClient := rpc.CreateClient(viper.GetString(constants.CfgOpennebulaEndpoint),
+		viper.GetString(constants.CfgOpennebulaSecret), &http.Client{})
+
 	return &Reader{
 		client:      oneClient,
+		rpcClient:   rpcClient,
 		rateLimiter: limiter,
 		timeout:     viper.GetDuration(constants.CfgOpennebulaTimeout),
+		cache:       createCache(),
 	}
 }
 
-func (r *Reader) readResources(rri resourcesReaderI) ([]resource.Resource, error) {
-	var res []resource.Resource
-	var err error
+// call calls OpenNebula method with retries. Every attempt waits for the rate limiter and it is canceled
+// after timeout or with the context.
+func (r *Reader) call(ctx context.Context, method string, f func(context.Context) error) error {
+	attempt := 0
 
-	err = retry.Do(func() error {
-		if err = r.rateLimiter.Wait(context.Background()); err != nil {
-			log.WithFields(log.Fields{"error": err}).Fatal("error list resources")
+	return retry.Do(func() error {
+		if attempt > 0 {
+			metrics.CountRetry(method)
 		}
+		attempt++
 
-		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		waitStart := time.Now()
+		if err := r.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+		metrics.ObserveWait(waitStart)
+
+		callCtx, cancel := context.WithTimeout(ctx, r.timeout)
 		defer cancel()
 
-		res, err = rri.ReadResources(ctx, r.client)
+		defer metrics.ObserveCall(method, time.Now())
 
-		return err
+		return f(callCtx)
 	}, attempts, sleepTime)
+}
+
+func (r *Reader) readResources(ctx context.Context, method string,
+	rri resourcesReaderI) ([]resource.Resource, error) {
+	var res []resource.Resource
+
+	err := r.call(ctx, method, func(callCtx context.Context) error {
+		var err error
+		res, err = rri.ReadResources(callCtx, r.client)
+
+		return err
+	})
 
 	return res, err
 }
 
-func (r *Reader) readResource(rri resourceReaderI) (resource.Resource, error) {
-	var res resource.Resource
-	var err error
-
-	err = retry.Do(func() error {
-		if err = r.rateLimiter.Wait(context.Background()); err != nil {
-			log.WithFields(log.Fields{"error": err}).Panic("error retrieve info")
-		}
+func (r *Reader) readCachedResources(ctx context.Context, method string,
+	rri resourcesReaderI) ([]resource.Resource, error) {
+	return r.cache.get(method, func() ([]resource.Resource, error) {
+		return r.readResources(ctx, method, rri)
+	})
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
-		defer cancel()
+func (r *Reader) readResource(ctx context.Context, method string, rri resourceReaderI) (resource.Resource, error) {
+	var res resource.Resource
 
-		res, err = rri.ReadResource(ctx, r.client)
+	err := r.call(ctx, method, func(callCtx context.Context) error {
+		var err error
+		res, err = rri.ReadResource(callCtx, r.client)
 
 		return err
-	}, attempts, sleepTime)
+	})
 
 	return res, err
 }
 
-func (r *Reader) readResourcesForUser(rri resourcesReaderForUserI) ([]resource.Resource, error) {
+func (r *Reader) readResourcesForUser(ctx context.Context, method string,
+	rri resourcesReaderForUserI) ([]resource.Resource, error) {
 	var res []resource.Resource
-	var err error
 
-	err = retry.Do(func() error {
-		if err = r.rateLimiter.Wait(context.Background()); err != nil {
-			log.WithFields(log.Fields{"error": err}).Fatal("error list resources")
-		}
+	err := r.call(ctx, method, func(callCtx context.Context) error {
+		var err error
+		res, err = rri.ReadResourcesForUser(callCtx, r.client)
 
-		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
-		defer cancel()
+		return err
+	})
 
-		res, err = rri.ReadResourcesForUser(ctx, r.client)
+	return res, err
+}
+
+func (r *Reader) readRPC(ctx context.Context, method string, rri rpcReaderI) ([]*etree.Element, error) {
+	var res []*etree.Element
+
+	err := r.call(ctx, method, func(callCtx context.Context) error {
+		var err error
+		res, err = rri.ReadRPC(callCtx, r.rpcClient)
 
 		return err
-	}, attempts, sleepTime)
+	})
 
 	return res, err
 }
 
 // ListAllVirtualMachines lists all virtual machines by page offset.
-func (r *Reader) ListAllVirtualMachines(pageOffset int) ([]*resources.VirtualMachine, error) {
+func (r *Reader) ListAllVirtualMachines(ctx context.Context, pageOffset int) ([]*resources.VirtualMachine, error) {
 	vmr := virtualMachineReader.VMsReader{
 		PageOffset: pageOffset,
 	}
 
-	res, err := r.readResources(&vmr)
+	res, err := r.readResources(ctx, "one.vmpool.info", &vmr)
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +189,13 @@ func (r *Reader) ListAllVirtualMachines(pageOffset int) ([]*resources.VirtualMac
 }
 
 // ListAllActiveVirtualMachinesForUser lists all virtual machines by page offset specific for a user given by id.
-func (r *Reader) ListAllActiveVirtualMachinesForUser(userID int) ([]*resources.VirtualMachine, error) {
+func (r *Reader) ListAllActiveVirtualMachinesForUser(ctx context.Context,
+	userID int) ([]*resources.VirtualMachine, error) {
 	vmr := virtualMachineReader.VMReaderForUser{
 		User: resources.CreateUserWithID(userID),
 	}
 
-	res, err := r.readResourcesForUser(&vmr)
+	res, err := r.readResourcesForUser(ctx, "one.vmpool.info", &vmr)
 	if err != nil {
 		return nil, err
 	}
@@ -161,12 +209,12 @@ func (r *Reader) ListAllActiveVirtualMachinesForUser(userID int) ([]*resources.V
 }
 
 // RetrieveVirtualMachineInfo returns virtual machines info by id.
-func (r *Reader) RetrieveVirtualMachineInfo(id int) (*resources.VirtualMachine, error) {
+func (r *Reader) RetrieveVirtualMachineInfo(ctx context.Context, id int) (*resources.VirtualMachine, error) {
 	vmr := virtualMachineReader.VMReader{
 		ID: id,
 	}
 
-	res, err := r.readResource(&vmr)
+	res, err := r.readResource(ctx, "one.vm.info", &vmr)
 	if err != nil {
 		return nil, err
 	}
@@ -174,11 +222,72 @@ func (r *Reader) RetrieveVirtualMachineInfo(id int) (*resources.VirtualMachine,
 	return res.(*resources.VirtualMachine), err
 }
 
-// ListAllUsers lists all users.
-func (r *Reader) ListAllUsers() ([]*resources.User, error) {
+// ListVirtualMachineAccounting lists history records of virtual machines from OpenNebula accounting
+// for a time window. Zero time means the window has no boundary.
+func (r *Reader) ListVirtualMachineAccounting(ctx context.Context, from, to time.Time) ([]*etree.Element, error) {
+	ar := virtualMachineReader.AccountingReader{
+		From: from,
+		To:   to,
+	}
+
+	return r.readRPC(ctx, "one.vmpool.accounting", &ar)
+}
+
+// ListAllActiveVirtualMachines lists active virtual machines of all users in one call.
+func (r *Reader) ListAllActiveVirtualMachines(ctx context.Context) ([]*resources.VirtualMachine, error) {
+	avr := virtualMachineReader.ActiveVMsReader{}
+
+	res, err := r.readRPC(ctx, "one.vmpool.info", &avr)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]*resources.VirtualMachine, len(res))
+	for i, e := range res {
+		vms[i] = resources.CreateVirtualMachineFromXML(e)
+	}
+
+	return vms, err
+}
+
+// ListAllVirtualMachinesExceptDone lists virtual machines of all users in any state except DONE in one call.
+func (r *Reader) ListAllVirtualMachinesExceptDone(ctx context.Context) ([]*resources.VirtualMachine, error) {
+	vedr := virtualMachineReader.VMsExceptDoneReader{}
+
+	res, err := r.readRPC(ctx, "one.vmpool.info", &vedr)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]*resources.VirtualMachine, len(res))
+	for i, e := range res {
+		vms[i] = resources.CreateVirtualMachineFromXML(e)
+	}
+
+	return vms, err
+}
+
+// ListAllVirtualNetworks lists virtual networks of all users with address ranges and their leases in one call.
+func (r *Reader) ListAllVirtualNetworks(ctx context.Context) ([]*etree.Element, error) {
+	vnr := networkReader.VirtualNetworksReader{}
+
+	return r.readRPC(ctx, "one.vnpool.info", &vnr)
+}
+
+// ListVirtualMachineMonitoring lists monitoring records of a virtual machine by id.
+func (r *Reader) ListVirtualMachineMonitoring(ctx context.Context, id int) ([]*etree.Element, error) {
+	mr := virtualMachineReader.MonitoringReader{
+		ID: id,
+	}
+
+	return r.readRPC(ctx, "one.vm.monitoring", &mr)
+}
+
+// ListAllUsers lists all users once.
+func (r *Reader) ListAllUsers(ctx context.Context) ([]*resources.User, error) {
 	or := resource.UserReader{}
 
-	res, err := r.readResources(&or)
+	res, err := r.readCachedResources(ctx, "one.userpool.info", &or)
 	if err != nil {
 		return nil, err
 	}
@@ -191,11 +300,11 @@ func (r *Reader) ListAllUsers() ([]*resources.User, error) {
 	return objs, err
 }
 
-// ListAllImages lists all images.
-func (r *Reader) ListAllImages() ([]*resources.Image, error) {
+// ListAllImages lists all images once.
+func (r *Reader) ListAllImages(ctx context.Context) ([]*resources.Image, error) {
 	or := storageReader.Reader{}
 
-	res, err := r.readResources(&or)
+	res, err := r.readCachedResources(ctx, "one.imagepool.info", &or)
 	if err != nil {
 		return nil, err
 	}
@@ -208,11 +317,18 @@ func (r *Reader) ListAllImages() ([]*resources.Image, error) {
 	return objs, err
 }
 
-// ListAllHosts lists all hosts.
-func (r *Reader) ListAllHosts() ([]*resources.Host, error) {
+// ListAllDatastores lists all datastores with their capacity in one call.
+func (r *Reader) ListAllDatastores(ctx context.Context) ([]*etree.Element, error) {
+	dr := storageReader.DatastoresReader{}
+
+	return r.readRPC(ctx, "one.datastorepool.info", &dr)
+}
+
+// ListAllHosts lists all hosts once.
+func (r *Reader) ListAllHosts(ctx context.Context) ([]*resources.Host, error) {
 	or := resource.HostReader{}
 
-	res, err := r.readResources(&or)
+	res, err := r.readCachedResources(ctx, "one.hostpool.info", &or)
 	if err != nil {
 		return nil, err
 	}
@@ -225,11 +341,11 @@ func (r *Reader) ListAllHosts() ([]*resources.Host, error) {
 	return objs, err
 }
 
-// ListAllClusters lists all clusters.
-func (r *Reader) ListAllClusters() ([]*resources.Cluster, error) {
+// ListAllClusters lists all clusters once.
+func (r *Reader) ListAllClusters(ctx context.Context) ([]*resources.Cluster, error) {
 	cr := resource.ClusterReader{}
 
-	res, err := r.readResources(&cr)
+	res, err := r.readCachedResources(ctx, "one.clusterpool.info", &cr)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// exit codes of accounting
+const (
+	// ExitOK represents accounting without skipped resources
+	ExitOK = 0
+	// ExitStopped represents accounting stopped since errors exceeded the error policy
+	ExitStopped = 1
+	// ExitSkipped represents finished accounting with skipped resources
+	ExitSkipped = 2
+	// ExitInterrupted represents accounting canceled by a signal after listed resources were written
+	ExitInterrupted = 3
+)
+
+// Reporter counts resources skipped because of an error and decides by the error policy whether
+// accounting continues.
+type Reporter struct {
+	policy    string
+	maxErrors int
+
+	mutex   sync.Mutex
+	count   int
+	skipped map[string]int
+}
+
+// CreateReporter creates Reporter with error policy (fail-fast/skip-and-report/max-errors=N).
+func CreateReporter(policy string) (*Reporter, error) {
+	r := &Reporter{
+		policy:  policy,
+		skipped: map[string]int{},
+	}
+
+	switch {
+	case policy == constants.PolicyFailFast, policy == constants.PolicySkipAndReport:
+	case strings.HasPrefix(policy, constants.PolicyMaxErrors+"="):
+		maxErrors, err := strconv.Atoi(strings.TrimPrefix(policy, constants.PolicyMaxErrors+"="))
+		if err != nil || maxErrors < 0 {
+			return nil, fmt.Errorf("wrong maximum number of errors in error policy %s", policy)
+		}
+
+		r.policy = constants.PolicyMaxErrors
+		r.maxErrors = maxErrors
+	default:
+		return nil, fmt.Errorf("unknown error policy %s", policy)
+	}
+
+	return r, nil
+}
+
+// Skip logs an error of a resource of a given type which is skipped and counts it by reason.
+func (r *Reporter) Skip(resource, reason string, fields log.Fields, err error) {
+	log.WithFields(fields).WithFields(log.Fields{"error": err, "resource": resource}).Error(reason)
+	metrics.Count(resource, metrics.StageFailed)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.count++
+	r.skipped[reason]++
+}
+
+// Reject counts a record of a given type which was rejected by validation as a skipped resource. The record
+// is logged and counted as rejected by the validating writer, so it is neither logged nor counted as failed again.
+func (r *Reporter) Reject(resource string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.count++
+	r.skipped[resource+" record rejected by validation"]++
+}
+
+// Stopped returns true when accounting has to stop since errors exceeded the error policy.
+func (r *Reporter) Stopped() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.stopped()
+}
+
+func (r *Reporter) stopped() bool {
+	switch r.policy {
+	case constants.PolicyFailFast:
+		return r.count > 0
+	case constants.PolicyMaxErrors:
+		return r.count > r.maxErrors
+	default:
+		return false
+	}
+}
+
+// Skipped returns number of skipped resources.
+func (r *Reporter) Skipped() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.count
+}
+
+// Summary logs how many resources were skipped and why.
+func (r *Reporter) Summary() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.count == 0 {
+		log.WithFields(log.Fields{}).Info("accounting finished")
+		return
+	}
+
+	for reason, count := range r.skipped {
+		log.WithFields(log.Fields{"reason": reason, "count": count}).Warn("resources skipped")
+	}
+
+	fields := log.Fields{"skipped": r.count, "error-policy": r.policy}
+	if r.stopped() {
+		log.WithFields(fields).Error("accounting stopped by error policy")
+		return
+	}
+
+	log.WithFields(fields).Warn("accounting finished with skipped resources")
+}
+
+// ExitCode returns exit code of accounting.
+func (r *Reporter) ExitCode() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch {
+	case r.count == 0:
+		return ExitOK
+	case r.stopped():
+		return ExitStopped
+	default:
+		return ExitSkipped
+	}
+}
+
+// ExitCodeOf returns exit code of accounting reported by more reporters, e.g. a reporter for every zone.
+// Accounting is stopped when it was stopped for any reporter.
+func ExitCodeOf(reporters ...*Reporter) int {
+	code := ExitOK
+	for _, r := range reporters {
+		switch c := r.ExitCode(); {
+		case c == ExitStopped:
+			return ExitStopped
+		case c != ExitOK:
+			code = c
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Reporter tests", func() {
+	var errTest = errors.New("virtual machine not found")
+
+	skip := func(r *Reporter, n int) {
+		for i := 0; i < n; i++ {
+			r.Skip("vm", "error retrieve virtual machine info", log.Fields{"id": i}, errTest)
+		}
+	}
+
+	ginkgo.Describe("create reporter", func() {
+		ginkgo.Context("when error policy is correct", func() {
+			ginkgo.It("should create reporter", func() {
+				for _, policy := range []string{"fail-fast", "skip-and-report", "max-errors=0", "max-errors=10"} {
+					r, err := CreateReporter(policy)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(r).NotTo(gomega.BeNil())
+				}
+			})
+		})
+
+		ginkgo.Context("when error policy is not correct", func() {
+			ginkgo.It("should return error", func() {
+				for _, policy := range []string{"", "ignore", "max-errors", "max-errors=", "max-errors=-1",
+					"max-errors=x"} {
+					r, err := CreateReporter(policy)
+					gomega.Expect(err).To(gomega.HaveOccurred())
+					gomega.Expect(r).To(gomega.BeNil())
+				}
+			})
+		})
+	})
+
+	ginkgo.Describe("skip resources", func() {
+		ginkgo.Context("when no resource is skipped", func() {
+			ginkgo.It("should not stop and exit with success", func() {
+				r, err := CreateReporter("fail-fast")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(r.Stopped()).To(gomega.BeFalse())
+				gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitOK))
+			})
+		})
+
+		ginkgo.Context("when error policy is fail-fast", func() {
+			ginkgo.It("should stop on the first error", func() {
+				r, err := CreateReporter("fail-fast")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				skip(r, 1)
+
+				gomega.Expect(r.Stopped()).To(gomega.BeTrue())
+				gomega.Expect(r.Skipped()).To(gomega.Equal(1))
+				gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitStopped))
+			})
+		})
+
+		ginkgo.Context("when error policy is skip-and-report", func() {
+			ginkgo.It("should not stop and report skipped resources", func() {
+				r, err := CreateReporter("skip-and-report")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				skip(r, 100)
+
+				gomega.Expect(r.Stopped()).To(gomega.BeFalse())
+				gomega.Expect(r.Skipped()).To(gomega.Equal(100))
+				gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitSkipped))
+			})
+		})
+
+		ginkgo.Context("when error policy is max-errors", func() {
+			ginkgo.It("should stop when errors exceed maximum", func() {
+				r, err := CreateReporter("max-errors=2")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				skip(r, 2)
+				gomega.Expect(r.Stopped()).To(gomega.BeFalse())
+				gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitSkipped))
+
+				skip(r, 1)
+				gomega.Expect(r.Stopped()).To(gomega.BeTrue())
+				gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitStopped))
+			})
+		})
+	})
+
+	ginkgo.Describe("reject records", func() {
+		ginkgo.It("should count rejected records as skipped resources", func() {
+			r, err := CreateReporter("max-errors=1")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			r.Reject("vm")
+			gomega.Expect(r.Skipped()).To(gomega.Equal(1))
+			gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitSkipped))
+
+			r.Reject("vm")
+			gomega.Expect(r.ExitCode()).To(gomega.Equal(ExitStopped))
+		})
+	})
+
+	ginkgo.Describe("exit code of more reporters", func() {
+		create := func(policy string, skipped int) *Reporter {
+			r, err := CreateReporter(policy)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			skip(r, skipped)
+
+			return r
+		}
+
+		ginkgo.It("should return the worst exit code", func() {
+			gomega.Expect(ExitCodeOf()).To(gomega.Equal(ExitOK))
+			gomega.Expect(ExitCodeOf(create("fail-fast", 0), create("fail-fast", 0))).To(gomega.Equal(ExitOK))
+			gomega.Expect(ExitCodeOf(create("skip-and-report", 1), create("fail-fast", 0))).To(
+				gomega.Equal(ExitSkipped))
+			gomega.Expect(ExitCodeOf(create("skip-and-report", 1), create("fail-fast", 1))).To(
+				gomega.Equal(ExitStopped))
+		})
+	})
+})
@@ -0,0 +1,231 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+
+	"github.com/onego-project/onego/resources"
+	"github.com/remeh/sizedwaitgroup"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IPUsage represents "Resource" with time IP addresses were held by virtual machines of a user
+// in the accounting window.
+type IPUsage struct {
+	User *resources.User
+	From time.Time
+	To   time.Time
+	IPv4 time.Duration
+	IPv6 time.Duration
+}
+
+// AccountingProcessor to process network data from OpenNebula accounting of virtual machines.
+type AccountingProcessor struct {
+	reader   reader.Reader
+	filter   Filter
+	reporter *report.Reporter
+}
+
+// CreateAccountingProcessor creates processor with reader, filter which window is used for accounting
+// and reporter of errors.
+func CreateAccountingProcessor(r *reader.Reader, f *Filter, rep *report.Reporter) *AccountingProcessor {
+	if r == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
+		return nil
+	}
+
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcFilterNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
+	return &AccountingProcessor{
+		reader:   *r,
+		filter:   *f,
+		reporter: rep,
+	}
+}
+
+// Process lists users and history records of virtual machines for the window, each of them in one call,
+// and writes time IP addresses were held by users to the read channel until the context is canceled.
+func (p *AccountingProcessor) Process(ctx context.Context, read chan resource.Resource, _ chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
+	defer swg.Done()
+
+	users, err := p.reader.ListAllUsers(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list users", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	records, err := p.reader.ListVirtualMachineAccounting(ctx, p.filter.recordsFrom, p.filter.recordsTo)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list virtual machine accounting", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	for _, usage := range createIPUsages(users, records, p.filter.recordsFrom, p.filter.recordsTo, time.Now()) {
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
+		read <- usage
+	}
+}
+
+// RetrieveInfo passes usage since OpenNebula accounting contains all the info.
+func (p *AccountingProcessor) RetrieveInfo(_ context.Context, fullInfo chan resource.Resource,
+	wg *sync.WaitGroup, usage resource.Resource) {
+	defer wg.Done()
+
+	fullInfo <- usage
+}
+
+// ID gets user ID - relevant method to implement "Resource".
+func (iu *IPUsage) ID() (int, error) {
+	return iu.User.ID()
+}
+
+// Attribute gets user attribute given by path - relevant method to implement "Resource".
+func (iu *IPUsage) Attribute(path string) (string, error) {
+	return iu.User.Attribute(path)
+}
+
+// Count returns number of IP addresses for a time IP addresses were held. It is the number of IP-hours or
+// the average number of IP addresses in the window given by configuration, rounded to the nearest integer.
+func (iu *IPUsage) Count(held time.Duration, ipCount string) uint32 {
+	if ipCount != constants.IPCountAverage {
+		return uint32(math.Round(held.Hours()))
+	}
+
+	window := iu.To.Sub(iu.From)
+	if window <= 0 {
+		return 0
+	}
+
+	return uint32(math.Round(float64(held) / float64(window)))
+}
+
+// CheckIPCount returns an error when the average number of IP addresses is counted in a window without start.
+// Such window would start at zero time, so the average would be rounded to zero.
+func CheckIPCount(ipCount string, f *Filter) error {
+	if from, _ := f.Window(); ipCount == constants.IPCountAverage && from.IsZero() {
+		return fmt.Errorf("average count of IP addresses needs start of the window (records-from or records-for-period)")
+	}
+
+	return nil
+}
+
+// createIPUsages sums time IP addresses were held by virtual machines of every user. Every history record
+// contains NICs a virtual machine had while it was running on a host, NICs attached or detached later are in
+// the next history record. Running periods are clipped to the window, a period without end is clipped to now.
+func createIPUsages(users []*resources.User, records []*etree.Element, from, to, now time.Time) []*IPUsage {
+	usersByID := map[int]*resources.User{}
+	for _, user := range users {
+		id, err := user.ID()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get user id")
+			continue
+		}
+
+		usersByID[id] = user
+	}
+
+	var usages []*IPUsage
+	byUser := map[int]*IPUsage{}
+
+	for _, record := range records {
+		held := heldInWindow(record, from, to, now)
+		if held <= 0 {
+			continue
+		}
+
+		data := record.SelectElement("VM")
+		if data == nil {
+			log.WithFields(log.Fields{}).Error("no virtual machine in history record")
+			continue
+		}
+
+		vm := resources.CreateVirtualMachineFromXML(data)
+
+		owner, err := vm.User()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get owner of virtual machine")
+			continue
+		}
+
+		countIPv4, countIPv6 := countIPs(NetUser{ActiveVirtualMachines: []*resources.VirtualMachine{vm}})
+		if countIPv4 == 0 && countIPv6 == 0 {
+			continue
+		}
+
+		usage, ok := byUser[owner]
+		if !ok {
+			user, ok := usersByID[owner]
+			if !ok {
+				log.WithFields(log.Fields{"user-id": owner}).Error("no owner of virtual machine in history record")
+				continue
+			}
+
+			usage = &IPUsage{User: user, From: from, To: to}
+			byUser[owner] = usage
+			usages = append(usages, usage)
+		}
+
+		usage.IPv4 += time.Duration(countIPv4) * held
+		usage.IPv6 += time.Duration(countIPv6) * held
+	}
+
+	return usages
+}
+
+// heldInWindow returns time a virtual machine was running within the window according to a history record.
+func heldInWindow(record *etree.Element, from, to, now time.Time) time.Duration {
+	rsTime, err := strconv.ParseInt(childText(record, "RSTIME"), 10, 64)
+	if err != nil || rsTime == 0 {
+		return 0
+	}
+
+	reTime, err := strconv.ParseInt(childText(record, "RETIME"), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	start, end := time.Unix(rsTime, 0), now
+	if reTime != 0 {
+		end = time.Unix(reTime, 0)
+	}
+
+	if start.Before(from) {
+		start = from
+	}
+
+	if !to.IsZero() && end.After(to) {
+		end = to
+	}
+
+	return end.Sub(start)
+}
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Network accounting tests", func() {
+	var (
+		users   []*resources.User
+		records []*etree.Element
+		from    time.Time
+		to      time.Time
+		now     time.Time
+	)
+
+	type held struct {
+		UserID int
+		IPv4   time.Duration
+		IPv6   time.Duration
+	}
+
+	helds := func(usages []*IPUsage) []held {
+		res := make([]held, len(usages))
+		for i, usage := range usages {
+			id, err := usage.ID()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			res[i] = held{UserID: id, IPv4: usage.IPv4, IPv6: usage.IPv6}
+		}
+
+		return res
+	}
+
+	ginkgo.BeforeEach(func() {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/users.xml")).NotTo(gomega.HaveOccurred())
+
+		users = nil
+		for _, e := range doc.Root().SelectElements("USER") {
+			users = append(users, resources.CreateUserFromXML(e))
+		}
+
+		doc = etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/accounting.xml")).NotTo(gomega.HaveOccurred())
+
+		records = doc.Root().SelectElements("HISTORY")
+
+		from = time.Unix(1540900000, 0)
+		to = time.Unix(1541100000, 0)
+		now = time.Unix(1541200000, 0)
+	})
+
+	ginkgo.Describe("create processor", func() {
+		ginkgo.Context("when reader is nil", func() {
+			ginkgo.It("should not create processor", func() {
+				rep, err := report.CreateReporter(constants.PolicyFailFast)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(CreateAccountingProcessor(nil, CreateWindowFilter(from, to), rep)).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Describe("create IP usages", func() {
+		ginkgo.Context("when history records overlap the window", func() {
+			ginkgo.It("should sum time IP addresses were held within the window by owner", func() {
+				usages := createIPUsages(users, records, from, to, now)
+
+				gomega.Expect(helds(usages)).To(gomega.Equal([]held{
+					{UserID: 46, IPv4: 200000 * time.Second, IPv6: 100000 * time.Second},
+					{UserID: 47, IPv4: time.Hour},
+				}))
+			})
+		})
+
+		ginkgo.Context("when history records are out of the window", func() {
+			ginkgo.It("should not create any usage", func() {
+				usages := createIPUsages(users, records, time.Unix(1520000000, 0), time.Unix(1520003600, 0), now)
+
+				gomega.Expect(usages).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when virtual machine is still running", func() {
+			ginkgo.It("should count the time to now", func() {
+				usages := createIPUsages(users, records[1:2], from, time.Time{}, now)
+
+				gomega.Expect(helds(usages)).To(gomega.Equal([]held{
+					{UserID: 46, IPv4: 200000 * time.Second, IPv6: 200000 * time.Second},
+				}))
+			})
+		})
+	})
+
+	ginkgo.Describe("count IP addresses", func() {
+		ginkgo.It("should return average or IP-hours rounded to the nearest integer", func() {
+			usage := &IPUsage{From: from, To: to}
+
+			gomega.Expect(usage.Count(200000*time.Second, constants.IPCountAverage)).To(gomega.Equal(uint32(1)))
+			gomega.Expect(usage.Count(time.Hour, constants.IPCountAverage)).To(gomega.Equal(uint32(0)))
+			gomega.Expect(usage.Count(400000*time.Second, constants.IPCountAverage)).To(gomega.Equal(uint32(2)))
+			gomega.Expect(usage.Count(200000*time.Second, constants.IPCountHours)).To(gomega.Equal(uint32(56)))
+			gomega.Expect(usage.Count(time.Hour, constants.IPCountHours)).To(gomega.Equal(uint32(1)))
+		})
+
+		ginkgo.It("should return IP-hours when count is not set", func() {
+			usage := &IPUsage{To: to}
+
+			gomega.Expect(usage.Count(time.Hour, "")).To(gomega.Equal(uint32(1)))
+		})
+	})
+
+	ginkgo.Describe("check count of IP addresses", func() {
+		ginkgo.Context("when window has no start", func() {
+			ginkgo.It("should reject average and accept IP-hours", func() {
+				filter := CreateWindowFilter(time.Time{}, to)
+
+				gomega.Expect(CheckIPCount(constants.IPCountAverage, filter)).NotTo(gomega.Succeed())
+				gomega.Expect(CheckIPCount(constants.IPCountHours, filter)).To(gomega.Succeed())
+			})
+		})
+
+		ginkgo.Context("when window has start", func() {
+			ginkgo.It("should accept average", func() {
+				gomega.Expect(CheckIPCount(constants.IPCountAverage, CreateWindowFilter(from, to))).To(gomega.Succeed())
+			})
+		})
+	})
+})
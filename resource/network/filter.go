@@ -2,20 +2,51 @@ package network
 
 import (
 	"sync"
+	"time"
 
 	"github.com/goat-project/goat-one/resource"
+	"github.com/goat-project/goat-one/window"
+
+	"github.com/onego-project/onego/resources"
+
+	log "github.com/sirupsen/logrus"
 )
 
-// Filter to filter network data.
+// Filter contains times from/to filter network records.
 type Filter struct {
+	recordsFrom time.Time
+	recordsTo   time.Time
 }
 
-// CreateFilter creates Filter.
+// CreateFilter creates Filter with the window from configuration.
 func CreateFilter() *Filter {
-	return &Filter{}
+	recordsFrom, recordsTo := window.Create()
+
+	return &Filter{
+		recordsFrom: recordsFrom,
+		recordsTo:   recordsTo,
+	}
 }
 
-// Filtering - only for VM relevant.
+// CreateWindowFilter creates Filter for a given window regardless of configuration.
+func CreateWindowFilter(recordsFrom, recordsTo time.Time) *Filter {
+	log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": recordsTo}).Debug("filter set by a window")
+
+	return &Filter{
+		recordsFrom: recordsFrom,
+		recordsTo:   recordsTo,
+	}
+}
+
+// Window returns times from/to the filter filters records.
+func (f *Filter) Window() (time.Time, time.Time) {
+	return f.recordsFrom, f.recordsTo
+}
+
+// Filtering filters users with virtual machines which were not running in the window and writes the others
+// to filtered channel. Users listed without virtual machines are filtered when their virtual machines are retrieved,
+// usages from virtual networks are leases at the time of the run and usages from accounting are already filtered
+// by the processor.
 func (f *Filter) Filtering(network resource.Resource, filtered chan resource.Resource, wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -23,5 +54,42 @@ func (f *Filter) Filtering(network resource.Resource, filtered chan resource.Res
 		return
 	}
 
+	if netUser, ok := network.(*NetUser); ok {
+		vms := f.runningVirtualMachines(netUser.ActiveVirtualMachines)
+		if len(vms) == 0 {
+			return
+		}
+
+		network = &NetUser{User: netUser.User, ActiveVirtualMachines: vms}
+	}
+
 	filtered <- network
 }
+
+// runningVirtualMachines returns virtual machines which were running in the window. Virtual machine is running
+// until its end time, virtual machine without end time is still running. Virtual machines without start time are
+// kept, since it is unknown when they were running.
+func (f *Filter) runningVirtualMachines(vms []*resources.VirtualMachine) []*resources.VirtualMachine {
+	var running []*resources.VirtualMachine
+
+	for _, vm := range vms {
+		stime, err := vm.STime()
+		if err != nil || stime == nil {
+			running = append(running, vm)
+			continue
+		}
+
+		if !f.recordsTo.IsZero() && stime.After(f.recordsTo) {
+			continue
+		}
+
+		etime, err := vm.ETime()
+		if err == nil && etime != nil && etime.Unix() > 0 && etime.Before(f.recordsFrom) {
+			continue
+		}
+
+		running = append(running, vm)
+	}
+
+	return running
+}
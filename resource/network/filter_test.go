@@ -1,7 +1,11 @@
 package network_test
 
 import (
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/beevik/etree"
 
 	"github.com/goat-project/goat-one/resource/network"
 
@@ -56,6 +60,32 @@ var _ = ginkgo.Describe("Network Filter tests", func() {
 			}, 0.2)
 		})
 
+		ginkgo.Context("when virtual machines of user were not running in the window", func() {
+			ginkgo.It("should post user only with virtual machines running in the window", func(done ginkgo.Done) {
+				filter = network.CreateWindowFilter(time.Unix(1540000000, 0), time.Unix(1541000000, 0))
+				filtered = make(chan resource.Resource)
+
+				running := createTestVirtualMachine(1, 1539000000, 0)
+				ended := createTestVirtualMachine(2, 1538000000, 1539000000)
+				started := createTestVirtualMachine(3, 1542000000, 0)
+				user := resources.CreateUserWithID(1)
+
+				go filter.Filtering(&network.NetUser{User: user,
+					ActiveVirtualMachines: []*resources.VirtualMachine{running, ended, started}}, filtered, &wg)
+
+				gomega.Expect(<-filtered).To(gomega.Equal(&network.NetUser{User: user,
+					ActiveVirtualMachines: []*resources.VirtualMachine{running}}))
+
+				wg.Add(1)
+				go filter.Filtering(&network.NetUser{User: user,
+					ActiveVirtualMachines: []*resources.VirtualMachine{ended, started}}, filtered, &wg)
+
+				gomega.Consistently(filtered).ShouldNot(gomega.Receive())
+
+				close(done)
+			}, 0.5)
+		})
+
 		// TODO add test with full channel
 		// we expect that the Filter waits until the channel is empty
 		// we need some test with timeout
@@ -64,6 +94,15 @@ var _ = ginkgo.Describe("Network Filter tests", func() {
 	})
 })
 
+func createTestVirtualMachine(id int, stime, etime int64) *resources.VirtualMachine {
+	e := etree.NewElement("VM")
+	e.CreateElement("ID").SetText(strconv.Itoa(id))
+	e.CreateElement("STIME").SetText(strconv.FormatInt(stime, 10))
+	e.CreateElement("ETIME").SetText(strconv.FormatInt(etime, 10))
+
+	return resources.CreateVirtualMachineFromXML(e)
+}
+
 func createTestNetwork(userID int) *network.NetUser {
 	vm1 := resources.CreateVirtualMachineWithID(1)
 	vm2 := resources.CreateVirtualMachineWithID(2)
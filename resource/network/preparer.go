@@ -1,24 +1,24 @@
 package network
 
 import (
+	"context"
 	"strconv"
 	"sync"
 	"time"
 
-	"google.golang.org/grpc"
-
 	"github.com/goat-project/goat-one/util"
 	"github.com/golang/protobuf/ptypes/wrappers"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/spf13/viper"
 
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 
 	"github.com/goat-project/goat-one/writer"
-
-	"golang.org/x/time/rate"
+	"github.com/onego-project/onego/resources"
 
 	pb "github.com/goat-project/goat-proto-go"
 	log "github.com/sirupsen/logrus"
@@ -26,28 +26,31 @@ import (
 
 // Preparer to prepare network data to specific structure for writing to Goat server.
 type Preparer struct {
-	Writer writer.Writer
+	reporter *report.Reporter
+	Writer   writer.Interface
 }
 
-// CreatePreparer creates Preparer for network records.
-func CreatePreparer(limiter *rate.Limiter, conn *grpc.ClientConn) *Preparer {
-	if limiter == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepLimiterNil)
+// CreatePreparer creates Preparer for network records. Records which cannot be prepared or written are reported
+// to the reporter.
+func CreatePreparer(w writer.Interface, rep *report.Reporter) *Preparer {
+	if w == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepWriterNil)
 		return nil
 	}
 
-	if conn == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepConnNil)
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepReporterNil)
 		return nil
 	}
 
 	return &Preparer{
-		Writer: *writer.CreateWriter(CreateWriter(limiter), conn),
+		reporter: rep,
+		Writer:   w,
 	}
 }
 
 // InitializeMaps - only for VM relevant.
-func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
+func (p *Preparer) InitializeMaps(_ context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 }
 
@@ -55,43 +58,102 @@ func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
 func (p *Preparer) Preparation(acc resource.Resource, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	switch usage := acc.(type) {
+	case *VnetUsage:
+		p.prepareVnetUsage(usage)
+		return
+	case *IPUsage:
+		p.prepareIPUsage(usage)
+		return
+	}
+
 	netUser := acc.(*NetUser)
 	if netUser.User == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrPrepEmptyNetUser)
+		p.reporter.Skip(constants.ResourceNetwork, constants.ErrPrepEmptyNetUser, log.Fields{}, nil)
 		return
 	}
 
 	id, err := netUser.ID()
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepNoNetUser)
+		p.reporter.Skip(constants.ResourceNetwork, constants.ErrPrepNoNetUser, log.Fields{}, err)
 		return
 	}
 
 	countIPv4, countIPv6 := countIPs(*netUser)
 
-	if countIPv4 != 0 {
-		ipv4Record, err := createIPRecord(*netUser, "IPv4", countIPv4)
+	p.prepareCounts(netUser.User, countIPv4, countIPv6, log.Fields{"user-id": id}, nil)
+}
+
+// prepareVnetUsage prepares records of IP addresses leased to a user from a virtual network and call method
+// to write. Name of the virtual network or label of the address ranges is set as cloud compute service.
+func (p *Preparer) prepareVnetUsage(usage *VnetUsage) {
+	p.prepareCounts(usage.User, usage.IPv4, usage.IPv6, log.Fields{"network": usage.Network},
+		func(record *pb.IpRecord) {
+			record.CloudComputeService = util.CheckValueErrStr(usage.Network, nil)
+		})
+}
+
+// prepareIPUsage prepares records of IP addresses held by a user in the accounting window and call method to write.
+// Counts are averages or IP-hours over the window and the end of the window is set as measurement time.
+func (p *Preparer) prepareIPUsage(usage *IPUsage) {
+	ipCount := viper.GetString(constants.CfgNetworkIPCount)
+
+	p.prepareCounts(usage.User, usage.Count(usage.IPv4, ipCount), usage.Count(usage.IPv6, ipCount),
+		log.Fields{"records-from": usage.From, "records-to": usage.To},
+		func(record *pb.IpRecord) {
+			record.MeasurementTime = &timestamp.Timestamp{Seconds: usage.To.Unix()}
+		})
+}
+
+// prepareCounts prepares IPv4 and IPv6 records of a user which are not zero, completes them when complete is set
+// and call method to write.
+func (p *Preparer) prepareCounts(user *resources.User, countIPv4, countIPv6 uint32, fields log.Fields,
+	complete func(*pb.IpRecord)) {
+	if user == nil {
+		p.reporter.Skip(constants.ResourceNetwork, constants.ErrPrepEmptyNetUser, log.Fields{}, nil)
+		return
+	}
+
+	counts := []struct {
+		ipType  string
+		ipCount uint32
+		errPrep string
+	}{{"IPv4", countIPv4, constants.ErrPrepIPv4}, {"IPv6", countIPv6, constants.ErrPrepIPv6}}
+
+	for _, count := range counts {
+		if count.ipCount == 0 {
+			continue
+		}
+
+		record, err := createIPRecord(NetUser{User: user}, count.ipType, count.ipCount)
 		if err != nil {
-			log.WithFields(log.Fields{"error": err, "user-id": id}).Error(constants.ErrPrepIPv4)
-			return
+			p.reporter.Skip(constants.ResourceNetwork, count.errPrep, fields, err)
+			continue
 		}
 
-		if err := p.Writer.Write(ipv4Record); err != nil {
-			log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepWrite)
+		if complete != nil {
+			complete(record)
 		}
+
+		p.write(record)
 	}
+}
 
-	if countIPv6 != 0 {
-		ipv6Record, err := createIPRecord(*netUser, "IPv6", countIPv6)
-		if err != nil {
-			log.WithFields(log.Fields{"error": err, "user-id": id}).Error(constants.ErrPrepIPv6)
+// write writes a prepared record and counts it.
+func (p *Preparer) write(record *pb.IpRecord) {
+	metrics.Count(constants.ResourceNetwork, metrics.StagePrepared)
+
+	if err := p.Writer.Write(record); err != nil {
+		if _, rejected := err.(*writer.RejectedError); rejected {
+			p.reporter.Reject(constants.ResourceNetwork)
 			return
 		}
 
-		if err := p.Writer.Write(ipv6Record); err != nil {
-			log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepWrite)
-		}
+		p.reporter.Skip(constants.ResourceNetwork, constants.ErrPrepWrite, log.Fields{}, err)
+		return
 	}
+
+	metrics.Count(constants.ResourceNetwork, metrics.StageWritten)
 }
 
 // SendIdentifier sends identifier to Goat server.
@@ -99,8 +161,7 @@ func (p *Preparer) SendIdentifier() error {
 	return p.Writer.SendIdentifier()
 }
 
-// Finish gets to know to the Goat server that a writing is finished and a response is expected.
-// Then, it closes the gRPC connection.
+// Finish gets to know to the writer that a writing is finished.
 func (p *Preparer) Finish() {
 	p.Writer.Finish()
 }
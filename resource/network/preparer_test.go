@@ -1,6 +1,7 @@
 package network_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -9,6 +10,8 @@ import (
 	"github.com/sirupsen/logrus/hooks/test"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/writer"
 	"github.com/spf13/viper"
 
 	"github.com/onego-project/onego/resources"
@@ -36,9 +39,10 @@ var _ = ginkgo.Describe("Network Preparer tests", func() {
 		rep     *rpcreplay.Replayer
 		conn    *grpc.ClientConn
 
-		prep *network.Preparer
-		wg   sync.WaitGroup
-		hook *test.Hook
+		prep     *network.Preparer
+		reporter *report.Reporter
+		wg       sync.WaitGroup
+		hook     *test.Hook
 	)
 
 	ginkgo.JustBeforeEach(func() {
@@ -68,7 +72,11 @@ var _ = ginkgo.Describe("Network Preparer tests", func() {
 
 		hook = test.NewGlobal()
 
-		prep = network.CreatePreparer(rate.NewLimiter(rate.Every(1), 1), conn)
+		w := writer.CreateWriter(network.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+		reporter, err = report.CreateReporter(constants.PolicySkipAndReport)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		prep = network.CreatePreparer(w, reporter)
 		wg.Add(1)
 	})
 
@@ -89,7 +97,7 @@ var _ = ginkgo.Describe("Network Preparer tests", func() {
 	})
 
 	ginkgo.Describe("create preparer", func() {
-		ginkgo.Context("when limiter is correct", func() {
+		ginkgo.Context("when writer is correct", func() {
 			ginkgo.BeforeEach(func() {
 				recName = "createOK"
 			})
@@ -97,41 +105,40 @@ var _ = ginkgo.Describe("Network Preparer tests", func() {
 			ginkgo.It("should create preparer", func() {
 				gomega.Expect(conn).NotTo(gomega.BeNil())
 
-				p := network.CreatePreparer(rate.NewLimiter(rate.Every(1), 1), conn)
+				w := writer.CreateWriter(network.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+				p := network.CreatePreparer(w, reporter)
 
 				gomega.Expect(p).NotTo(gomega.BeNil())
 			})
 		})
 
-		ginkgo.Context("when limiter is not correct", func() {
+		ginkgo.Context("when writer is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "limiterNil"
+				recName = "writerNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
-				gomega.Expect(conn).NotTo(gomega.BeNil())
-
-				p := network.CreatePreparer(nil, conn)
+				p := network.CreatePreparer(nil, reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepLimiterNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepWriterNil))
 			})
 		})
 
-		ginkgo.Context("when connection is not correct", func() {
+		ginkgo.Context("when reporter is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "connectionNil"
+				recName = "writerNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
-				p := network.CreatePreparer(rate.NewLimiter(rate.Every(1), 1), nil)
+				p := network.CreatePreparer(prep.Writer, nil)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepConnNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepReporterNil))
 			})
 		})
 	})
@@ -143,7 +150,7 @@ var _ = ginkgo.Describe("Network Preparer tests", func() {
 			})
 
 			ginkgo.It("should do nothing", func() {
-				prep.InitializeMaps(&wg)
+				prep.InitializeMaps(context.Background(), &wg)
 			})
 		})
 	})
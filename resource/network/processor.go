@@ -1,11 +1,13 @@
 package network
 
 import (
+	"context"
 	"sync"
 
 	"github.com/goat-project/goat-one/constants"
 
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 	"github.com/onego-project/onego/resources"
 
@@ -16,7 +18,9 @@ import (
 
 // Processor to process network data.
 type Processor struct {
-	reader reader.Reader
+	reader   reader.Reader
+	filter   Filter
+	reporter *report.Reporter
 }
 
 // NetUser represents "Resource" with information about user and his active virtual machines.
@@ -25,46 +29,77 @@ type NetUser struct {
 	ActiveVirtualMachines []*resources.VirtualMachine
 }
 
-// CreateProcessor creates Processor to manage reading from OpenNebula.
-func CreateProcessor(r *reader.Reader) *Processor {
+// CreateProcessor creates Processor to manage reading from OpenNebula, filtering of virtual machines which were
+// not running in the window of the filter and reporting of skipped users.
+func CreateProcessor(r *reader.Reader, f *Filter, rep *report.Reporter) *Processor {
 	if r == nil {
 		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
 		return nil
 	}
 
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcFilterNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
 	return &Processor{
-		reader: *r,
+		reader:   *r,
+		filter:   *f,
+		reporter: rep,
 	}
 }
 
-// Process provides listing of the users.
-func (p *Processor) Process(read chan resource.Resource, _ chan bool, swg *sizedwaitgroup.SizedWaitGroup) {
+// Process provides listing of the users until the context is canceled.
+func (p *Processor) Process(ctx context.Context, read chan resource.Resource, _ chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
 	defer swg.Done()
 
-	users, err := p.reader.ListAllUsers()
+	users, err := p.reader.ListAllUsers(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error list users")
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list users", log.Fields{}, err)
+		}
+
+		return
 	}
 
 	for _, user := range users {
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
 		read <- user
 	}
 }
 
-// RetrieveInfo about virtual machines specific for a given user.
-func (p *Processor) RetrieveInfo(fullInfo chan resource.Resource, wg *sync.WaitGroup, user resource.Resource) {
+// RetrieveInfo about virtual machines specific for a given user. Users without virtual machines running
+// in the window are omitted, users are not reported as skipped when the context is canceled.
+func (p *Processor) RetrieveInfo(ctx context.Context, fullInfo chan resource.Resource, wg *sync.WaitGroup,
+	user resource.Resource) {
 	defer wg.Done()
 
 	id, err := user.ID()
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error get user id")
+		p.reporter.Skip(constants.ResourceNetwork, "error get user id", log.Fields{}, err)
+		return
 	}
 
-	vms, err := p.reader.ListAllActiveVirtualMachinesForUser(id)
+	vms, err := p.reader.ListAllActiveVirtualMachinesForUser(ctx, id)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "userID": id}).Fatal("error retrieve virtual machines for user")
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error retrieve virtual machines for user",
+				log.Fields{"userID": id}, err)
+		}
+
+		return
 	}
 
+	vms = p.filter.runningVirtualMachines(vms)
 	if len(vms) != 0 {
 		fullInfo <- &NetUser{
 			User:                  user.(*resources.User),
@@ -1,6 +1,7 @@
 package network_test
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/dnaeon/go-vcr/recorder"
 	"github.com/goat-project/goat-one/constants"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource/network"
 	"github.com/goat-project/goat-one/util"
 	"github.com/onego-project/onego"
@@ -40,6 +42,7 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 
 		proc *network.Processor
 		read *reader.Reader
+		rep  *report.Reporter
 
 		channel chan resource.Resource
 
@@ -73,7 +76,10 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 
 		read = reader.CreateReader(client, rate.NewLimiter(rate.Every(time.Second/time.Duration(30)), 30))
 
-		proc = network.CreateProcessor(read)
+		rep, err = report.CreateReporter(constants.PolicyFailFast)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		proc = network.CreateProcessor(read, network.CreateFilter(), rep)
 
 		channel = make(chan resource.Resource)
 	})
@@ -88,7 +94,7 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 	ginkgo.Describe("create processor", func() {
 		ginkgo.Context("when read is correct", func() {
 			ginkgo.It("should create processor", func() {
-				p := network.CreateProcessor(read)
+				p := network.CreateProcessor(read, network.CreateFilter(), rep)
 
 				gomega.Expect(p).NotTo(gomega.BeNil())
 			})
@@ -96,7 +102,7 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 
 		ginkgo.Context("when reader is not correct", func() {
 			ginkgo.It("should not create processor", func() {
-				p := network.CreateProcessor(nil)
+				p := network.CreateProcessor(nil, network.CreateFilter(), rep)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
@@ -104,6 +110,28 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReaderNil))
 			})
 		})
+
+		ginkgo.Context("when filter is not correct", func() {
+			ginkgo.It("should not create processor", func() {
+				p := network.CreateProcessor(read, nil, rep)
+
+				gomega.Expect(p).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcFilterNil))
+			})
+		})
+
+		ginkgo.Context("when reporter is not correct", func() {
+			ginkgo.It("should not create processor", func() {
+				p := network.CreateProcessor(read, network.CreateFilter(), nil)
+
+				gomega.Expect(p).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReporterNil))
+			})
+		})
 	})
 
 	ginkgo.Describe("process", func() {
@@ -113,7 +141,7 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 			})
 
 			ginkgo.It("should post resource to the channel", func(done ginkgo.Done) {
-				go proc.Process(channel, nil, &swg)
+				go proc.Process(context.Background(), channel, nil, &swg)
 
 				x := <-channel
 				y := <-channel
@@ -137,7 +165,7 @@ var _ = ginkgo.Describe("Network Processor tests", func() {
 
 				user := resources.CreateUserWithID(0)
 
-				go proc.RetrieveInfo(channel, &wg, user)
+				go proc.RetrieveInfo(context.Background(), channel, &wg, user)
 
 				nu := <-channel
 				gomega.Expect(nu.ID()).To(gomega.Equal(0))
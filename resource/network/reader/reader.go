@@ -0,0 +1,27 @@
+package reader
+
+import (
+	"context"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/rpc"
+)
+
+// VirtualNetworksReader structure for a Reader which read virtual networks with address ranges and their leases.
+type VirtualNetworksReader struct {
+}
+
+const virtualNetworksMethod = "one.vnpool.info"
+
+// allResources is an OpenNebula filter flag for resources of all users.
+const allResources = -2
+
+// ReadRPC reads all virtual networks in one call.
+func (vnr *VirtualNetworksReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, virtualNetworksMethod, allResources, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.SelectElements("VNET"), nil
+}
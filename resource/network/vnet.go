@@ -0,0 +1,284 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/goat-project/goat-one/util"
+
+	"github.com/onego-project/onego/resources"
+	"github.com/remeh/sizedwaitgroup"
+	"github.com/spf13/viper"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// VnetUsage represents "Resource" with numbers of IP addresses leased to a user from a virtual network or from address
+// ranges with the same label.
+type VnetUsage struct {
+	User    *resources.User
+	Network string
+	IPv4    uint32
+	IPv6    uint32
+}
+
+// VirtualNetworkProcessor to process network data from leases of virtual networks.
+type VirtualNetworkProcessor struct {
+	reader   reader.Reader
+	reporter *report.Reporter
+}
+
+// CreateVirtualNetworkProcessor creates processor to manage reading of virtual networks from OpenNebula
+// and reporting of errors.
+func CreateVirtualNetworkProcessor(r *reader.Reader, rep *report.Reporter) *VirtualNetworkProcessor {
+	if r == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
+	return &VirtualNetworkProcessor{
+		reader:   *r,
+		reporter: rep,
+	}
+}
+
+// Process lists users, virtual networks and virtual machines in any state except DONE, each of them in one call,
+// and writes IP addresses leased to users to the read channel until the context is canceled.
+func (p *VirtualNetworkProcessor) Process(ctx context.Context, read chan resource.Resource, _ chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
+	defer swg.Done()
+
+	users, err := p.reader.ListAllUsers(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list users", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	vnets, err := p.reader.ListAllVirtualNetworks(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list virtual networks", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	vms, err := p.reader.ListAllVirtualMachinesExceptDone(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceNetwork, "error list virtual machines", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	for _, usage := range createVnetUsages(users, vnets, vms, viper.GetString(constants.CfgNetworkGroupBy)) {
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
+		read <- usage
+	}
+}
+
+// RetrieveInfo passes usage since leases of virtual networks contain all the info.
+func (p *VirtualNetworkProcessor) RetrieveInfo(_ context.Context, fullInfo chan resource.Resource,
+	wg *sync.WaitGroup, usage resource.Resource) {
+	defer wg.Done()
+
+	fullInfo <- usage
+}
+
+// ID gets user ID - relevant method to implement "Resource".
+func (vu *VnetUsage) ID() (int, error) {
+	return vu.User.ID()
+}
+
+// Attribute gets user attribute given by path - relevant method to implement "Resource".
+func (vu *VnetUsage) Attribute(path string) (string, error) {
+	return vu.User.Attribute(path)
+}
+
+// leaseOnHold is set as virtual machine of a lease which is on hold.
+const leaseOnHold = -1
+
+type usageKey struct {
+	userID  int
+	network string
+}
+
+// createVnetUsages counts IP addresses leased from address ranges by owner of the lease and by virtual network
+// or label of address range. Lease of a virtual machine in any state belongs to the owner of the virtual machine,
+// lease on hold belongs to the owner of the virtual network and lease reserved to another virtual network belongs
+// to the owner of the reservation. Leases of reservations themselves are counted in their parent virtual network.
+// Public IPv4 addresses and global IPv6 addresses are counted in the same way as for users. Leases of virtual
+// routers are omitted.
+func createVnetUsages(users []*resources.User, vnets []*etree.Element, vms []*resources.VirtualMachine,
+	groupBy string) []*VnetUsage {
+	usersByID := map[int]*resources.User{}
+	for _, user := range users {
+		id, err := user.ID()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get user id")
+			continue
+		}
+
+		usersByID[id] = user
+	}
+
+	owners := leaseOwners{vms: map[int]int{}, vnets: map[int]int{}}
+	for _, vm := range vms {
+		id, err := vm.ID()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get virtual machine id")
+			continue
+		}
+
+		owner, err := vm.User()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "id": id}).Error("error get owner of virtual machine")
+			continue
+		}
+
+		owners.vms[id] = owner
+	}
+
+	for _, vnet := range vnets {
+		id, err := strconv.Atoi(childText(vnet, "ID"))
+		if err != nil {
+			continue
+		}
+
+		owner, err := strconv.Atoi(childText(vnet, "UID"))
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "vnet-id": id}).Error("error get owner of virtual network")
+			continue
+		}
+
+		owners.vnets[id] = owner
+	}
+
+	var usages []*VnetUsage
+	byKey := map[usageKey]*VnetUsage{}
+
+	for _, vnet := range vnets {
+		if isReservation(vnet) {
+			continue
+		}
+
+		name := childText(vnet, "NAME")
+		vnetID, _ := strconv.Atoi(childText(vnet, "ID"))
+
+		for _, ar := range vnet.FindElements("AR_POOL/AR") {
+			network := name
+			if label := childText(ar, "LABEL"); groupBy == constants.GroupByLabel && label != "" {
+				network = label
+			}
+
+			for _, lease := range ar.FindElements("LEASES/LEASE") {
+				owner, ok := owners.of(lease, vnetID)
+				if !ok {
+					continue
+				}
+
+				user, ok := usersByID[owner]
+				if !ok {
+					log.WithFields(log.Fields{"user-id": owner, "vnet-id": vnetID,
+						"ip": childText(lease, "IP")}).Error("no owner of leased IP address")
+					continue
+				}
+
+				var ipv4, ipv6 uint32
+				if util.IsPublicIPv4(net.ParseIP(childText(lease, "IP"))) {
+					ipv4 = 1
+				} else if childText(lease, "IP6_GLOBAL") != "" {
+					ipv6 = 1
+				} else {
+					continue
+				}
+
+				key := usageKey{userID: owner, network: network}
+				usage, ok := byKey[key]
+				if !ok {
+					usage = &VnetUsage{User: user, Network: network}
+					byKey[key] = usage
+					usages = append(usages, usage)
+				}
+
+				usage.IPv4 += ipv4
+				usage.IPv6 += ipv6
+			}
+		}
+	}
+
+	return usages
+}
+
+// leaseOwners contains owners of virtual machines and of virtual networks by their IDs.
+type leaseOwners struct {
+	vms   map[int]int
+	vnets map[int]int
+}
+
+// of returns owner of a lease from a virtual network with a given ID. Lease of a virtual machine which is not listed
+// and lease of a virtual router have no owner.
+func (o leaseOwners) of(lease *etree.Element, vnetID int) (int, bool) {
+	if vm := childText(lease, "VM"); vm != "" {
+		vmID, err := strconv.Atoi(vm)
+		if err != nil {
+			return 0, false
+		}
+
+		if vmID == leaseOnHold {
+			owner, ok := o.vnets[vnetID]
+			return owner, ok
+		}
+
+		owner, ok := o.vms[vmID]
+		return owner, ok
+	}
+
+	if vnet := childText(lease, "VNET"); vnet != "" {
+		reservationID, err := strconv.Atoi(vnet)
+		if err != nil {
+			return 0, false
+		}
+
+		owner, ok := o.vnets[reservationID]
+		return owner, ok
+	}
+
+	return 0, false
+}
+
+// isReservation returns true when a virtual network is a reservation from a parent virtual network.
+func isReservation(vnet *etree.Element) bool {
+	parent := childText(vnet, "PARENT_NETWORK_ID")
+
+	return parent != "" && parent != "-1"
+}
+
+func childText(e *etree.Element, tag string) string {
+	child := e.SelectElement(tag)
+	if child == nil {
+		return ""
+	}
+
+	return child.Text()
+}
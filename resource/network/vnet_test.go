@@ -0,0 +1,129 @@
+package network
+
+import (
+	"context"
+	"sync"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Virtual network tests", func() {
+	var (
+		users []*resources.User
+		vnets []*etree.Element
+		vms   []*resources.VirtualMachine
+	)
+
+	read := func(path, tag string) []*etree.Element {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile(path)).NotTo(gomega.HaveOccurred())
+
+		return doc.Root().SelectElements(tag)
+	}
+
+	type count struct {
+		UserID  int
+		Network string
+		IPv4    uint32
+		IPv6    uint32
+	}
+
+	counts := func(usages []*VnetUsage) []count {
+		res := make([]count, len(usages))
+		for i, usage := range usages {
+			id, err := usage.ID()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			res[i] = count{UserID: id, Network: usage.Network, IPv4: usage.IPv4, IPv6: usage.IPv6}
+		}
+
+		return res
+	}
+
+	ginkgo.BeforeEach(func() {
+		users = nil
+		for _, e := range read("test/xml/users.xml", "USER") {
+			users = append(users, resources.CreateUserFromXML(e))
+		}
+
+		vms = nil
+		for _, e := range read("test/xml/vms.xml", "VM") {
+			vms = append(vms, resources.CreateVirtualMachineFromXML(e))
+		}
+
+		vnets = read("test/xml/vnets.xml", "VNET")
+	})
+
+	ginkgo.Describe("create processor", func() {
+		ginkgo.Context("when reader is nil", func() {
+			ginkgo.It("should not create processor", func() {
+				rep, err := report.CreateReporter(constants.PolicyFailFast)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(CreateVirtualNetworkProcessor(nil, rep)).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Describe("create virtual network usages", func() {
+		ginkgo.Context("when IP addresses are grouped by virtual network", func() {
+			ginkgo.It("should count leases by owner and virtual network", func() {
+				usages := createVnetUsages(users, vnets, vms, constants.GroupByVirtualNetwork)
+
+				gomega.Expect(counts(usages)).To(gomega.Equal([]count{
+					{UserID: 46, Network: "public", IPv4: 3},
+					{UserID: 47, Network: "public", IPv4: 2},
+					{UserID: 46, Network: "private", IPv6: 1},
+					{UserID: 47, Network: "user-net", IPv4: 1},
+				}))
+			})
+		})
+
+		ginkgo.Context("when IP addresses are grouped by label", func() {
+			ginkgo.It("should count leases by label of address range or by virtual network without label", func() {
+				usages := createVnetUsages(users, vnets, vms, constants.GroupByLabel)
+
+				gomega.Expect(counts(usages)).To(gomega.Equal([]count{
+					{UserID: 46, Network: "public-pool-a", IPv4: 2},
+					{UserID: 47, Network: "public", IPv4: 2},
+					{UserID: 46, Network: "public", IPv4: 1},
+					{UserID: 46, Network: "private", IPv6: 1},
+					{UserID: 47, Network: "user-net", IPv4: 1},
+				}))
+			})
+		})
+
+		ginkgo.Context("when there are no virtual machines", func() {
+			ginkgo.It("should count leases on hold and reservations only", func() {
+				usages := createVnetUsages(users, vnets, nil, constants.GroupByVirtualNetwork)
+
+				gomega.Expect(counts(usages)).To(gomega.Equal([]count{
+					{UserID: 46, Network: "public", IPv4: 1},
+					{UserID: 47, Network: "user-net", IPv4: 1},
+				}))
+			})
+		})
+	})
+
+	ginkgo.Describe("retrieve info", func() {
+		ginkgo.It("should post usage to the channel", func(done ginkgo.Done) {
+			p := &VirtualNetworkProcessor{}
+			fullInfo := make(chan resource.Resource)
+			usage := &VnetUsage{User: users[0], Network: "public", IPv4: 1}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go p.RetrieveInfo(context.Background(), fullInfo, &wg, usage)
+
+			gomega.Expect(<-fullInfo).To(gomega.Equal(usage))
+			wg.Wait()
+			close(done)
+		})
+	})
+})
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+
+	"github.com/onego-project/onego/resources"
+	"github.com/remeh/sizedwaitgroup"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Datastore represents "Resource" with capacity of a datastore accounted for the window given by From and To.
+type Datastore struct {
+	XMLData *etree.Element
+	From    time.Time
+	To      time.Time
+}
+
+// DatastoreUsage represents "Resource" with capacity used by images of a user in a group in a datastore.
+type DatastoreUsage struct {
+	Datastore *Datastore
+	User      int
+	Group     int
+	GroupName string
+	Size      uint64
+}
+
+// DatastoreProcessor to process storage data of datastores.
+type DatastoreProcessor struct {
+	reader   reader.Reader
+	filter   Filter
+	reporter *report.Reporter
+}
+
+type datastoreUsageKey struct {
+	user  int
+	group int
+}
+
+// CreateDatastoreProcessor creates processor with reader, filter which window is used for accounting
+// and reporter of errors.
+func CreateDatastoreProcessor(r *reader.Reader, f *Filter, rep *report.Reporter) *DatastoreProcessor {
+	if r == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
+		return nil
+	}
+
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcFilterNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
+	return &DatastoreProcessor{
+		reader:   *r,
+		filter:   *f,
+		reporter: rep,
+	}
+}
+
+// Process lists datastores and images, each of them in one call, and writes every datastore followed by capacity
+// used by images of every user and group in the datastore to the read channel until the context is canceled.
+func (p *DatastoreProcessor) Process(ctx context.Context, read chan resource.Resource, _ chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
+	defer swg.Done()
+
+	elements, err := p.reader.ListAllDatastores(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceStorage, "error list datastores", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	images, err := p.reader.ListAllImages(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceStorage, "error list images", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	for _, e := range elements {
+		ds := &Datastore{XMLData: e, From: p.filter.recordsFrom, To: p.filter.recordsTo}
+
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
+		read <- ds
+
+		for _, usage := range createDatastoreUsages(ds, images, &p.filter) {
+			if ctx.Err() != nil || p.reporter.Stopped() {
+				return
+			}
+
+			read <- usage
+		}
+	}
+}
+
+// RetrieveInfo passes datastore or its usage since listing contains all the info.
+func (p *DatastoreProcessor) RetrieveInfo(_ context.Context, fullInfo chan resource.Resource,
+	wg *sync.WaitGroup, res resource.Resource) {
+	defer wg.Done()
+
+	fullInfo <- res
+}
+
+// ID gets datastore ID - relevant method to implement "Resource".
+func (d *Datastore) ID() (int, error) {
+	id, err := d.Attribute("ID")
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(id)
+}
+
+// Attribute gets datastore attribute given by path - relevant method to implement "Resource".
+func (d *Datastore) Attribute(path string) (string, error) {
+	if d.XMLData == nil {
+		return "", fmt.Errorf("no datastore data")
+	}
+
+	e := d.XMLData.FindElement(path)
+	if e == nil {
+		return "", fmt.Errorf("no datastore attribute %s", path)
+	}
+
+	return e.Text(), nil
+}
+
+// capacity returns capacity of the datastore given by path in bytes. Capacity is given in MB by OpenNebula.
+func (d *Datastore) capacity(path string) (uint64, error) {
+	value, err := d.Attribute(path)
+	if err != nil {
+		return 0, err
+	}
+
+	mb, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return mb * 1024 * 1024, nil
+}
+
+// ID gets datastore ID - relevant method to implement "Resource".
+func (du *DatastoreUsage) ID() (int, error) {
+	return du.Datastore.ID()
+}
+
+// Attribute gets datastore attribute given by path - relevant method to implement "Resource".
+func (du *DatastoreUsage) Attribute(path string) (string, error) {
+	return du.Datastore.Attribute(path)
+}
+
+// createDatastoreUsages sums sizes of images in a datastore by user and group. Images registered after
+// the window of the filter are omitted.
+func createDatastoreUsages(ds *Datastore, images []*resources.Image, f *Filter) []*DatastoreUsage {
+	dsID, err := ds.Attribute("ID")
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("error get datastore id")
+		return nil
+	}
+
+	var usages []*DatastoreUsage
+	byKey := map[datastoreUsageKey]*DatastoreUsage{}
+
+	for _, image := range images {
+		if id, err := image.Attribute("DATASTORE_ID"); err != nil || id != dsID || !f.registeredInWindow(image) {
+			continue
+		}
+
+		uid, err := image.User()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "datastore": dsID}).Error("error get owner of image")
+			continue
+		}
+
+		gid, err := image.Group()
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "datastore": dsID}).Error("error get group of image")
+			continue
+		}
+
+		size, err := getResourceCapacityUsed(image)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "datastore": dsID}).Error("error get size of image")
+			continue
+		}
+
+		key := datastoreUsageKey{user: uid, group: gid}
+		usage, ok := byKey[key]
+		if !ok {
+			groupName, _ := image.Attribute("GNAME")
+			usage = &DatastoreUsage{Datastore: ds, User: uid, Group: gid, GroupName: groupName}
+			byKey[key] = usage
+			usages = append(usages, usage)
+		}
+
+		usage.Size += size
+	}
+
+	return usages
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Datastore tests", func() {
+	var (
+		datastores []*Datastore
+		images     []*resources.Image
+		filter     *Filter
+	)
+
+	read := func(path, tag string) []*etree.Element {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile(path)).NotTo(gomega.HaveOccurred())
+
+		return doc.Root().SelectElements(tag)
+	}
+
+	type size struct {
+		User      int
+		Group     int
+		GroupName string
+		Size      uint64
+	}
+
+	sizes := func(usages []*DatastoreUsage) []size {
+		res := make([]size, len(usages))
+		for i, usage := range usages {
+			res[i] = size{User: usage.User, Group: usage.Group, GroupName: usage.GroupName, Size: usage.Size}
+		}
+
+		return res
+	}
+
+	ginkgo.BeforeEach(func() {
+		filter = CreateWindowFilter(time.Unix(1540000000, 0), time.Unix(1541000000, 0))
+
+		datastores = nil
+		for _, e := range read("test/xml/datastores.xml", "DATASTORE") {
+			datastores = append(datastores, &Datastore{XMLData: e, From: filter.recordsFrom, To: filter.recordsTo})
+		}
+
+		images = nil
+		for _, e := range read("test/xml/images.xml", "IMAGE") {
+			images = append(images, resources.CreateImageFromXML(e))
+		}
+	})
+
+	ginkgo.Describe("create processor", func() {
+		ginkgo.Context("when reader is nil", func() {
+			ginkgo.It("should not create processor", func() {
+				rep, err := report.CreateReporter(constants.PolicyFailFast)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(CreateDatastoreProcessor(nil, filter, rep)).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Describe("create datastore usages", func() {
+		ginkgo.Context("when images of several users are in the datastore", func() {
+			ginkgo.It("should sum sizes of images registered in the window by user and group", func() {
+				gomega.Expect(sizes(createDatastoreUsages(datastores[0], images, filter))).To(gomega.Equal([]size{
+					{User: 46, Group: 100, GroupName: "users", Size: 3072 * 1024 * 1024},
+					{User: 47, Group: 101, GroupName: "project", Size: 10240 * 1024 * 1024},
+				}))
+			})
+		})
+
+		ginkgo.Context("when there are no images in the datastore", func() {
+			ginkgo.It("should not create any usage", func() {
+				gomega.Expect(createDatastoreUsages(datastores[1], images[:4], filter)).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("datastore capacity", func() {
+		ginkgo.Context("when capacity is set", func() {
+			ginkgo.It("should return capacity in bytes", func() {
+				used, err := datastores[0].capacity("USED_MB")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(used).To(gomega.Equal(uint64(24000 * 1024 * 1024)))
+			})
+		})
+
+		ginkgo.Context("when capacity is missing", func() {
+			ginkgo.It("should return error", func() {
+				_, err := (&Datastore{XMLData: etree.NewElement("DATASTORE")}).capacity("USED_MB")
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("create datastore record", func() {
+		ginkgo.It("should set datastore attributes and the window", func() {
+			record, err := createDatastoreRecord(datastores[0], 46, 100)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(record.StorageShare.GetValue()).To(gomega.Equal("default"))
+			gomega.Expect(record.StorageMedia.GetValue()).To(gomega.Equal("qcow2"))
+			gomega.Expect(record.StorageClass.GetValue()).To(gomega.Equal("fs"))
+			gomega.Expect(record.StartTime.GetSeconds()).To(gomega.Equal(int64(1540000000)))
+			gomega.Expect(record.EndTime.GetSeconds()).To(gomega.Equal(int64(1541000000)))
+		})
+	})
+
+	ginkgo.Describe("retrieve info", func() {
+		ginkgo.It("should post datastore to the channel", func(done ginkgo.Done) {
+			p := &DatastoreProcessor{}
+			fullInfo := make(chan resource.Resource)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go p.RetrieveInfo(context.Background(), fullInfo, &wg, datastores[0])
+
+			gomega.Expect(<-fullInfo).To(gomega.Equal(datastores[0]))
+			wg.Wait()
+			close(done)
+		})
+	})
+})
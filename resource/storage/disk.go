@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+
+	"github.com/onego-project/onego/resources"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// VirtualMachineDisk represents "Resource" with capacity of a virtual machine disk not accounted as an image.
+// It is a volatile disk, a clone of a non-persistent image or a snapshot of a disk of the virtual machine given
+// by storage class.
+type VirtualMachineDisk struct {
+	VirtualMachine *resources.VirtualMachine
+	DiskID         int
+	SnapshotID     int
+	Class          string
+	Created        time.Time
+	Size           uint64
+}
+
+// ID gets virtual machine ID - relevant method to implement "Resource".
+func (d *VirtualMachineDisk) ID() (int, error) {
+	return d.VirtualMachine.ID()
+}
+
+// Attribute gets virtual machine attribute given by path - relevant method to implement "Resource".
+func (d *VirtualMachineDisk) Attribute(path string) (string, error) {
+	return d.VirtualMachine.Attribute(path)
+}
+
+// createVirtualMachineDisks creates volatile disks, clones of non-persistent images and snapshots of disks
+// of a virtual machine. Persistent images are used by the virtual machine directly, so they are accounted only
+// as images. Non-persistent images are cloned (CLONE=YES) to the system datastore for the virtual machine,
+// so their clones take capacity besides the images. Volatile disks and clones are created when the virtual
+// machine is started, snapshots at their date. Sizes are given in MB by OpenNebula.
+func createVirtualMachineDisks(vm *resources.VirtualMachine) []*VirtualMachineDisk {
+	id, err := vm.ID()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("error get virtual machine id")
+		return nil
+	}
+
+	var vmDisks []*VirtualMachineDisk
+
+	disks, err := vm.Disks()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "id": id}).Error("error get disks of virtual machine")
+		return nil
+	}
+
+	sTime, err := vm.STime()
+	if err != nil || sTime == nil {
+		log.WithFields(log.Fields{"error": err, "id": id}).Error("error get start time of virtual machine")
+	} else {
+		for _, disk := range disks {
+			class := diskClass(vm, disk.DiskID)
+			if class == "" {
+				continue
+			}
+
+			vmDisks = append(vmDisks, &VirtualMachineDisk{VirtualMachine: vm, DiskID: disk.DiskID, SnapshotID: -1,
+				Class: class, Created: *sTime, Size: uint64(disk.Size) * 1024 * 1024})
+		}
+	}
+
+	if vm.XMLData == nil {
+		return vmDisks
+	}
+
+	for _, snapshots := range vm.XMLData.SelectElements("SNAPSHOTS") {
+		diskID, err := strconv.Atoi(childText(snapshots, "DISK_ID"))
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "id": id}).Error("error get disk id of snapshots")
+			continue
+		}
+
+		for _, snapshot := range snapshots.SelectElements("SNAPSHOT") {
+			vmDisk, err := createSnapshotDisk(vm, diskID, snapshot)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err, "id": id, "disk-id": diskID}).Error(
+					"error get snapshot of disk")
+				continue
+			}
+
+			vmDisks = append(vmDisks, vmDisk)
+		}
+	}
+
+	return vmDisks
+}
+
+// diskClass returns storage class of a disk of a virtual machine given by ID or empty string when the disk
+// is a persistent image accounted as the image.
+func diskClass(vm *resources.VirtualMachine, diskID int) string {
+	path := fmt.Sprintf("TEMPLATE/DISK[DISK_ID='%d']/", diskID)
+	if _, err := vm.Attribute(path + "IMAGE_ID"); err != nil {
+		return constants.StorageClassVolatile
+	}
+
+	if clone, err := vm.Attribute(path + "CLONE"); err == nil && clone == "YES" {
+		return constants.StorageClassClone
+	}
+
+	return ""
+}
+
+// createSnapshotDisk creates snapshot of a disk of a virtual machine from SNAPSHOT element.
+func createSnapshotDisk(vm *resources.VirtualMachine, diskID int, snapshot *etree.Element) (*VirtualMachineDisk,
+	error) {
+	snapshotID, err := strconv.Atoi(childText(snapshot, "ID"))
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := strconv.ParseInt(childText(snapshot, "DATE"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := strconv.ParseUint(childText(snapshot, "SIZE"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualMachineDisk{VirtualMachine: vm, DiskID: diskID, SnapshotID: snapshotID,
+		Class: constants.StorageClassSnapshot, Created: time.Unix(date, 0), Size: size * 1024 * 1024}, nil
+}
+
+// childText returns text of a child element given by tag or empty string when there is no such child.
+func childText(e *etree.Element, tag string) string {
+	child := e.SelectElement(tag)
+	if child == nil {
+		return ""
+	}
+
+	return child.Text()
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	pb "github.com/goat-project/goat-proto-go"
+)
+
+var _ = ginkgo.Describe("Virtual machine disk tests", func() {
+	var vms []*resources.VirtualMachine
+
+	type capacity struct {
+		VirtualMachineID int
+		DiskID           int
+		SnapshotID       int
+		Class            string
+		Created          int64
+		Size             uint64
+	}
+
+	capacities := func(disks []*VirtualMachineDisk) []capacity {
+		res := make([]capacity, len(disks))
+		for i, disk := range disks {
+			id, err := disk.ID()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			res[i] = capacity{VirtualMachineID: id, DiskID: disk.DiskID, SnapshotID: disk.SnapshotID,
+				Class: disk.Class, Created: disk.Created.Unix(), Size: disk.Size}
+		}
+
+		return res
+	}
+
+	ginkgo.BeforeEach(func() {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/vms.xml")).NotTo(gomega.HaveOccurred())
+
+		vms = nil
+		for _, e := range doc.Root().SelectElements("VM") {
+			vms = append(vms, resources.CreateVirtualMachineFromXML(e))
+		}
+	})
+
+	ginkgo.Describe("create virtual machine disks", func() {
+		ginkgo.Context("when virtual machine has volatile disks, clones and snapshots", func() {
+			ginkgo.It("should create disks not accounted as images", func() {
+				gomega.Expect(capacities(createVirtualMachineDisks(vms[0]))).To(gomega.Equal([]capacity{
+					{VirtualMachineID: 20, DiskID: 0, SnapshotID: -1, Class: constants.StorageClassClone,
+						Created: 1539000000, Size: 2048 * 1024 * 1024},
+					{VirtualMachineID: 20, DiskID: 1, SnapshotID: -1, Class: constants.StorageClassVolatile,
+						Created: 1539000000, Size: 10240 * 1024 * 1024},
+					{VirtualMachineID: 20, DiskID: 2, SnapshotID: -1, Class: constants.StorageClassVolatile,
+						Created: 1539000000, Size: 1024 * 1024 * 1024},
+					{VirtualMachineID: 20, DiskID: 0, SnapshotID: 0, Class: constants.StorageClassSnapshot,
+						Created: 1539500000, Size: 2048 * 1024 * 1024},
+					{VirtualMachineID: 20, DiskID: 0, SnapshotID: 1, Class: constants.StorageClassSnapshot,
+						Created: 1542000000, Size: 2048 * 1024 * 1024},
+				}))
+			})
+		})
+
+		ginkgo.Context("when virtual machine has only persistent images", func() {
+			ginkgo.It("should not create any disk", func() {
+				gomega.Expect(createVirtualMachineDisks(vms[1])).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("prepare virtual machine disk", func() {
+		ginkgo.It("should return the same ID for the disk prepared at different times", func() {
+			rw := &recordingWriter{}
+			p := &Preparer{Writer: rw, filter: *CreateWindowFilter(time.Unix(1540000000, 0),
+				time.Unix(1541000000, 0))}
+			disk := createVirtualMachineDisks(vms[0])[1]
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			p.Preparation(disk, &wg)
+			time.Sleep(1100 * time.Millisecond)
+			wg.Add(1)
+			p.Preparation(disk, &wg)
+
+			gomega.Expect(rw.records).To(gomega.HaveLen(2))
+			first, second := rw.records[0].(*pb.StorageRecord), rw.records[1].(*pb.StorageRecord)
+			gomega.Expect(first.RecordID).To(gomega.Equal(second.RecordID))
+			gomega.Expect(first.StorageClass.GetValue()).To(gomega.Equal(constants.StorageClassVolatile))
+			gomega.Expect(first.StartTime.GetSeconds()).To(gomega.Equal(int64(1540000000)))
+			gomega.Expect(first.EndTime.GetSeconds()).To(gomega.Equal(int64(1541000000)))
+		})
+	})
+
+	ginkgo.Describe("filter virtual machine disks", func() {
+		ginkgo.It("should not post disks created after the window to the channel", func(done ginkgo.Done) {
+			filter := CreateWindowFilter(time.Unix(1540000000, 0), time.Unix(1541000000, 0))
+			filtered := make(chan resource.Resource, 5)
+
+			var wg sync.WaitGroup
+			for _, disk := range createVirtualMachineDisks(vms[0]) {
+				wg.Add(1)
+				go filter.Filtering(disk, filtered, &wg)
+			}
+
+			wg.Wait()
+			close(filtered)
+
+			var snapshots []int
+			for res := range filtered {
+				if disk := res.(*VirtualMachineDisk); disk.Class == constants.StorageClassSnapshot {
+					snapshots = append(snapshots, disk.SnapshotID)
+				}
+			}
+
+			gomega.Expect(snapshots).To(gomega.Equal([]int{0}))
+			close(done)
+		})
+	})
+})
@@ -2,20 +2,46 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"github.com/goat-project/goat-one/resource"
+	"github.com/goat-project/goat-one/window"
+
+	"github.com/onego-project/onego/resources"
+
+	log "github.com/sirupsen/logrus"
 )
 
-// Filter to filter storage data.
+// Filter contains times from/to filter storage records.
 type Filter struct {
+	recordsFrom time.Time
+	recordsTo   time.Time
 }
 
-// CreateFilter creates Filter.
+// CreateFilter creates Filter with the window from configuration.
 func CreateFilter() *Filter {
-	return &Filter{}
+	recordsFrom, recordsTo := window.Create()
+
+	return &Filter{
+		recordsFrom: recordsFrom,
+		recordsTo:   recordsTo,
+	}
 }
 
-// Filtering - only for VM relevant.
+// CreateWindowFilter creates Filter for a given window regardless of configuration.
+func CreateWindowFilter(recordsFrom, recordsTo time.Time) *Filter {
+	log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": recordsTo}).Debug("filter set by a window")
+
+	return &Filter{
+		recordsFrom: recordsFrom,
+		recordsTo:   recordsTo,
+	}
+}
+
+// Filtering filters images registered after the window and writes the others to filtered channel. Deleted images
+// are not listed by OpenNebula, so every listed image exists until now and it is in the window unless it was
+// registered after the window. Images without registration time are written, preparation of their records fails.
+// Disks of virtual machines created after the window are filtered as well.
 func (f *Filter) Filtering(storage resource.Resource, filtered chan resource.Resource, wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -23,5 +49,23 @@ func (f *Filter) Filtering(storage resource.Resource, filtered chan resource.Res
 		return
 	}
 
+	if image, ok := storage.(*resources.Image); ok && !f.registeredInWindow(image) {
+		return
+	}
+
+	if disk, ok := storage.(*VirtualMachineDisk); ok && !f.recordsTo.IsZero() && disk.Created.After(f.recordsTo) {
+		return
+	}
+
 	filtered <- storage
 }
+
+// registeredInWindow returns false when an image was registered after the window.
+func (f *Filter) registeredInWindow(image *resources.Image) bool {
+	regTime, err := image.RegistrationTime()
+	if err != nil || regTime == nil || f.recordsTo.IsZero() {
+		return true
+	}
+
+	return !regTime.After(f.recordsTo)
+}
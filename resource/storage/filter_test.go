@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/beevik/etree"
 
 	"github.com/goat-project/goat-one/resource"
 	"github.com/onego-project/onego/resources"
@@ -52,6 +56,33 @@ var _ = ginkgo.Describe("Storage Filter tests", func() {
 			}, 0.2)
 		})
 
+		ginkgo.Context("when image was registered after the window", func() {
+			ginkgo.It("should not post storage to the channel", func(done ginkgo.Done) {
+				filter = CreateWindowFilter(time.Unix(1540000000, 0), time.Unix(1541000000, 0))
+				filtered = make(chan resource.Resource)
+
+				go filter.Filtering(createTestImage(1542000000), filtered, &wg)
+
+				gomega.Consistently(filtered).ShouldNot(gomega.Receive())
+
+				close(done)
+			}, 0.5)
+		})
+
+		ginkgo.Context("when image was registered before the end of the window", func() {
+			ginkgo.It("should post storage to the channel", func(done ginkgo.Done) {
+				filter = CreateWindowFilter(time.Unix(1540000000, 0), time.Unix(1541000000, 0))
+				filtered = make(chan resource.Resource)
+				image := createTestImage(1530000000)
+
+				go filter.Filtering(image, filtered, &wg)
+
+				gomega.Expect(<-filtered).To(gomega.Equal(image))
+
+				close(done)
+			}, 0.2)
+		})
+
 		// TODO add test with full channel
 		// we expect that the Filter waits until the channel is empty
 		// we need some test with timeout
@@ -59,3 +90,12 @@ var _ = ginkgo.Describe("Storage Filter tests", func() {
 		// possibly we should test also null channel or null wait group, but that situations should never happen
 	})
 })
+
+func createTestImage(regTime int64) *resources.Image {
+	e := etree.NewElement("IMAGE")
+	e.CreateElement("ID").SetText("1")
+	e.CreateElement("REGTIME").SetText(strconv.FormatInt(regTime, 10))
+	e.CreateElement("SIZE").SetText("1024")
+
+	return resources.CreateImageFromXML(e)
+}
@@ -1,14 +1,15 @@
 package storage
 
 import (
+	"context"
+	"strconv"
 	"sync"
 	"time"
 
-	"google.golang.org/grpc"
-
 	"github.com/goat-project/goat-one/initialize"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/goat-project/goat-one/reader"
 	"github.com/goat-project/goat-one/util"
 	"github.com/golang/protobuf/ptypes/wrappers"
@@ -16,59 +17,67 @@ import (
 
 	"github.com/golang/protobuf/ptypes/timestamp"
 
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 	"github.com/goat-project/goat-one/writer"
 
-	"golang.org/x/time/rate"
-
 	"github.com/onego-project/onego/errors"
 	"github.com/onego-project/onego/resources"
 
 	pb "github.com/goat-project/goat-proto-go"
 
 	log "github.com/sirupsen/logrus"
-
-	"github.com/beevik/guid"
 )
 
 // Preparer to prepare storage data to specific structure for writing to Goat server.
 type Preparer struct {
 	reader               reader.Reader
-	Writer               writer.Writer
+	filter               Filter
+	reporter             *report.Reporter
+	Writer               writer.Interface
 	userTemplateIdentity map[int]string
 }
 
-// CreatePreparer creates Preparer for storage records.
-func CreatePreparer(reader *reader.Reader, limiter *rate.Limiter, conn *grpc.ClientConn) *Preparer {
+// CreatePreparer creates Preparer for storage records. Records end at the end of the filter window, so records
+// of a storage prepared for the same window have the same ID regardless of time they are prepared at. Records which
+// cannot be prepared or written are reported to the reporter.
+func CreatePreparer(reader *reader.Reader, w writer.Interface, f *Filter, rep *report.Reporter) *Preparer {
 	if reader == nil {
 		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepReaderNil)
 		return nil
 	}
 
-	if limiter == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepLimiterNil)
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepFilterNil)
+		return nil
+	}
+
+	if w == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepWriterNil)
 		return nil
 	}
 
-	if conn == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepConnNil)
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepReporterNil)
 		return nil
 	}
 
 	return &Preparer{
-		reader: *reader,
-		Writer: *writer.CreateWriter(CreateWriter(limiter), conn),
+		reader:   *reader,
+		filter:   *f,
+		reporter: rep,
+		Writer:   w,
 	}
 }
 
 // InitializeMaps reads additional data for storage record.
-func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
+func (p *Preparer) InitializeMaps(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		p.userTemplateIdentity = initialize.UserTemplateIdentity(p.reader)
+		p.userTemplateIdentity = initialize.UserTemplateIdentity(ctx, p.reader)
 	}()
 }
 
@@ -76,35 +85,48 @@ func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
 func (p *Preparer) Preparation(acc resource.Resource, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	switch res := acc.(type) {
+	case *Datastore:
+		p.prepareDatastore(res)
+		return
+	case *DatastoreUsage:
+		p.prepareDatastoreUsage(res)
+		return
+	case *VirtualMachineDisk:
+		p.prepareVirtualMachineDisk(res)
+		return
+	}
+
 	storage := acc.(*resources.Image)
 	if storage == nil {
-		log.WithFields(log.Fields{"error": errors.ErrNoImage}).Error(constants.ErrPrepEmptyImage)
+		p.reporter.Skip(constants.ResourceStorage, constants.ErrPrepEmptyImage, log.Fields{}, errors.ErrNoImage)
 		return
 	}
 
 	id, err := storage.ID()
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepNoImage)
+		p.reporter.Skip(constants.ResourceStorage, constants.ErrPrepNoImage, log.Fields{}, err)
 		return
 	}
 
-	startTime, err := getStartTime(storage)
+	startTime, err := getStartTime(storage, p.filter.recordsFrom)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepRegTime)
+		p.reporter.Skip(constants.ResourceStorage, constants.ErrPrepRegTime, log.Fields{"id": id}, err)
 		return
 	}
 
 	size, err := getResourceCapacityUsed(storage)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepSize)
+		p.reporter.Skip(constants.ResourceStorage, constants.ErrPrepSize, log.Fields{"id": id}, err)
 		return
 	}
 
-	now := time.Now().Unix()
+	now := time.Now()
+	endTime := getEndTime(p.filter.recordsTo)
 
 	storageRecord := pb.StorageRecord{
-		RecordID:      guid.New().String(),
-		CreateTime:    &timestamp.Timestamp{Seconds: now},
+		RecordID:      getRecordID(id, startTime, endTime),
+		CreateTime:    &timestamp.Timestamp{Seconds: now.Unix()},
 		StorageSystem: viper.GetString(constants.CfgOpennebulaEndpoint),
 		Site:          getSite(),
 		StorageShare:  getStorageShare(storage),
@@ -119,15 +141,132 @@ func (p *Preparer) Preparation(acc resource.Resource, wg *sync.WaitGroup) {
 		// GroupAttribute: nil,
 		// GroupAttributeType: nil,
 		StartTime:                 startTime,
-		EndTime:                   &timestamp.Timestamp{Seconds: now},
+		EndTime:                   endTime,
 		ResourceCapacityUsed:      size,
 		LogicalCapacityUsed:       &wrappers.UInt64Value{Value: size},
 		ResourceCapacityAllocated: &wrappers.UInt64Value{Value: size},
 	}
 
-	if err := p.Writer.Write(&storageRecord); err != nil {
-		log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepWrite)
+	p.write(&storageRecord)
+}
+
+// prepareDatastore prepares record with capacity of a datastore and call method to write. Used and total
+// capacity of the datastore are used and allocated capacity of the record.
+func (p *Preparer) prepareDatastore(ds *Datastore) {
+	record, err := createDatastoreRecord(ds)
+	if err != nil {
+		p.reporter.Skip(constants.ResourceStorage, "unable to prepare datastore record", log.Fields{}, err)
+		return
+	}
+
+	used, err := ds.capacity("USED_MB")
+	if err != nil {
+		p.reporter.Skip(constants.ResourceStorage, "error get USED_MB, unable to prepare datastore record",
+			log.Fields{"record-id": record.RecordID}, err)
+		return
+	}
+
+	total, err := ds.capacity("TOTAL_MB")
+	if err != nil {
+		p.reporter.Skip(constants.ResourceStorage, "error get TOTAL_MB, unable to prepare datastore record",
+			log.Fields{"record-id": record.RecordID}, err)
+		return
+	}
+
+	record.ResourceCapacityUsed = used
+	record.LogicalCapacityUsed = &wrappers.UInt64Value{Value: used}
+	record.ResourceCapacityAllocated = &wrappers.UInt64Value{Value: total}
+
+	p.write(record)
+}
+
+// prepareDatastoreUsage prepares record with capacity used by images of a user in a group in a datastore and call
+// method to write.
+func (p *Preparer) prepareDatastoreUsage(usage *DatastoreUsage) {
+	record, err := createDatastoreRecord(usage.Datastore, usage.User, usage.Group)
+	if err != nil {
+		p.reporter.Skip(constants.ResourceStorage, "unable to prepare datastore record",
+			log.Fields{"user": usage.User, "group": usage.Group}, err)
+		return
+	}
+
+	record.LocalUser = &wrappers.StringValue{Value: strconv.Itoa(usage.User)}
+	record.LocalGroup = &wrappers.StringValue{Value: strconv.Itoa(usage.Group)}
+	if ui := p.userTemplateIdentity[usage.User]; ui != "" {
+		record.UserIdentity = &wrappers.StringValue{Value: ui}
+	}
+	if usage.GroupName != "" {
+		record.Group = &wrappers.StringValue{Value: "/" + usage.GroupName + "/Role=NULL/Capability=NULL"}
+	}
+
+	record.ResourceCapacityUsed = usage.Size
+	record.LogicalCapacityUsed = &wrappers.UInt64Value{Value: usage.Size}
+	record.ResourceCapacityAllocated = &wrappers.UInt64Value{Value: usage.Size}
+
+	p.write(record)
+}
+
+// prepareVirtualMachineDisk prepares record with capacity of a volatile disk, a clone of an image or a snapshot
+// of a disk of a virtual machine attributed to the owner of the virtual machine and call method to write.
+func (p *Preparer) prepareVirtualMachineDisk(disk *VirtualMachineDisk) {
+	id, err := disk.ID()
+	if err != nil {
+		p.reporter.Skip(constants.ResourceStorage, "unable to prepare virtual machine disk record", log.Fields{}, err)
+		return
+	}
+
+	now := time.Now()
+	startTime := &timestamp.Timestamp{Seconds: disk.Created.Unix()}
+	if disk.Created.Before(p.filter.recordsFrom) {
+		startTime = &timestamp.Timestamp{Seconds: p.filter.recordsFrom.Unix()}
+	}
+
+	endTime := getEndTime(p.filter.recordsTo)
+
+	record := &pb.StorageRecord{
+		RecordID: util.RecordID(viper.GetString(constants.CfgOpennebulaEndpoint), constants.ResourceStorage, "vm", id,
+			disk.DiskID, disk.SnapshotID, startTime.GetSeconds(), endTime.GetSeconds()),
+		CreateTime:                &timestamp.Timestamp{Seconds: now.Unix()},
+		StorageSystem:             viper.GetString(constants.CfgOpennebulaEndpoint),
+		Site:                      getSite(),
+		StorageMedia:              &wrappers.StringValue{Value: "disk"},
+		StorageClass:              &wrappers.StringValue{Value: disk.Class},
+		FileCount:                 &wrappers.StringValue{Value: "1"},
+		LocalUser:                 util.CheckValueErrInt(disk.VirtualMachine.User()),
+		LocalGroup:                util.CheckValueErrInt(disk.VirtualMachine.Group()),
+		StartTime:                 startTime,
+		EndTime:                   endTime,
+		ResourceCapacityUsed:      disk.Size,
+		LogicalCapacityUsed:       &wrappers.UInt64Value{Value: disk.Size},
+		ResourceCapacityAllocated: &wrappers.UInt64Value{Value: disk.Size},
+	}
+
+	if uid, err := disk.VirtualMachine.User(); err == nil && p.userTemplateIdentity[uid] != "" {
+		record.UserIdentity = &wrappers.StringValue{Value: p.userTemplateIdentity[uid]}
+	}
+
+	if groupName, err := disk.Attribute("GNAME"); err == nil {
+		record.Group = &wrappers.StringValue{Value: "/" + groupName + "/Role=NULL/Capability=NULL"}
+	}
+
+	p.write(record)
+}
+
+// write writes a prepared record and counts it.
+func (p *Preparer) write(record *pb.StorageRecord) {
+	metrics.Count(constants.ResourceStorage, metrics.StagePrepared)
+
+	if err := p.Writer.Write(record); err != nil {
+		if _, rejected := err.(*writer.RejectedError); rejected {
+			p.reporter.Reject(constants.ResourceStorage)
+			return
+		}
+
+		p.reporter.Skip(constants.ResourceStorage, constants.ErrPrepWrite, log.Fields{}, err)
+		return
 	}
+
+	metrics.Count(constants.ResourceStorage, metrics.StageWritten)
 }
 
 // SendIdentifier sends identifier to Goat server.
@@ -135,8 +274,7 @@ func (p *Preparer) SendIdentifier() error {
 	return p.Writer.SendIdentifier()
 }
 
-// Finish gets to know to the Goat server that a writing is finished and a response is expected.
-// Then, it closes the gRPC connection.
+// Finish gets to know to the writer that a writing is finished.
 func (p *Preparer) Finish() {
 	p.Writer.Finish()
 }
@@ -186,15 +324,32 @@ func getGroup(storage *resources.Image) *wrappers.StringValue {
 	return nil
 }
 
-func getStartTime(storage *resources.Image) (*timestamp.Timestamp, error) {
+// getStartTime returns start of the measurement window of an image which is its registration time or the time
+// records are filtered from when the image was registered before the window.
+func getStartTime(storage *resources.Image, recordsFrom time.Time) (*timestamp.Timestamp, error) {
 	rs, err := util.CheckTime(storage.RegistrationTime())
 	if err != nil {
 		return nil, err
 	}
 
+	if rs != nil && rs.GetSeconds() < recordsFrom.Unix() {
+		return &timestamp.Timestamp{Seconds: recordsFrom.Unix()}, nil
+	}
+
 	return rs, nil
 }
 
+// getEndTime returns end of the measurement window which is time records are filtered to.
+func getEndTime(recordsTo time.Time) *timestamp.Timestamp {
+	return &timestamp.Timestamp{Seconds: recordsTo.Unix()}
+}
+
+// getRecordID returns record ID of a storage derived from OpenNebula endpoint, image ID and the measurement window.
+func getRecordID(id int, startTime, endTime *timestamp.Timestamp) string {
+	return util.RecordID(viper.GetString(constants.CfgOpennebulaEndpoint), constants.ResourceStorage, id,
+		startTime.GetSeconds(), endTime.GetSeconds())
+}
+
 func getResourceCapacityUsed(storage *resources.Image) (uint64, error) {
 	size, err := storage.Size()
 	if err != nil {
@@ -203,3 +358,36 @@ func getResourceCapacityUsed(storage *resources.Image) (uint64, error) {
 
 	return uint64(size * 1024 * 1024), nil
 }
+
+// createDatastoreRecord creates record of a datastore for the window without capacity. Storage media and class are
+// transfer and datastore drivers of the datastore. Record ID is derived from the datastore ID, the window and
+// given parts identifying the record in the datastore. The window without start is accounted as its end.
+func createDatastoreRecord(ds *Datastore, parts ...interface{}) (*pb.StorageRecord, error) {
+	id, err := ds.ID()
+	if err != nil {
+		return nil, err
+	}
+
+	from := ds.From
+	if from.IsZero() {
+		from = ds.To
+	}
+
+	startTime := &timestamp.Timestamp{Seconds: from.Unix()}
+	endTime := &timestamp.Timestamp{Seconds: ds.To.Unix()}
+
+	recordParts := append([]interface{}{constants.ResourceStorage, "datastore", id}, parts...)
+	recordParts = append(recordParts, startTime.GetSeconds(), endTime.GetSeconds())
+
+	return &pb.StorageRecord{
+		RecordID:      util.RecordID(viper.GetString(constants.CfgOpennebulaEndpoint), recordParts...),
+		CreateTime:    &timestamp.Timestamp{Seconds: time.Now().Unix()},
+		StorageSystem: viper.GetString(constants.CfgOpennebulaEndpoint),
+		Site:          getSite(),
+		StorageShare:  util.CheckValueErrStr(ds.Attribute("NAME")),
+		StorageMedia:  util.CheckValueErrStr(ds.Attribute("TM_MAD")),
+		StorageClass:  util.CheckValueErrStr(ds.Attribute("DS_MAD")),
+		StartTime:     startTime,
+		EndTime:       endTime,
+	}, nil
+}
@@ -1,13 +1,42 @@
 package storage
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/writer"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/onego-project/onego/resources"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 	"github.com/spf13/viper"
+
+	pb "github.com/goat-project/goat-proto-go"
 )
 
+type recordingWriter struct {
+	records []writer.Record
+	err     error
+}
+
+func (rw *recordingWriter) Write(rec writer.Record) error {
+	if rw.err != nil {
+		return rw.err
+	}
+
+	rw.records = append(rw.records, rec)
+	return nil
+}
+
+func (rw *recordingWriter) SendIdentifier() error {
+	return nil
+}
+
+func (rw *recordingWriter) Finish() {}
+
 // the following tests test additive preparer functions
 
 var _ = ginkgo.Describe("Preparer function test", func() {
@@ -81,4 +110,107 @@ var _ = ginkgo.Describe("Preparer function test", func() {
 
 		// TODO add storage XML and test correct getGID()
 	})
+	ginkgo.Describe("getEndTime", func() {
+		ginkgo.It("should return records to", func() {
+			gomega.Expect(getEndTime(time.Unix(1540000000, 0)).GetSeconds()).To(gomega.Equal(int64(1540000000)))
+		})
+	})
+
+	ginkgo.Describe("getStartTime", func() {
+		from := time.Unix(1539000000, 0)
+
+		ginkgo.Context("when image was registered before the window", func() {
+			ginkgo.It("should return records from", func() {
+				startTime, err := getStartTime(createTestImage(1530000000), from)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(startTime.GetSeconds()).To(gomega.Equal(int64(1539000000)))
+			})
+		})
+
+		ginkgo.Context("when image was registered in the window", func() {
+			ginkgo.It("should return registration time", func() {
+				startTime, err := getStartTime(createTestImage(1539500000), from)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(startTime.GetSeconds()).To(gomega.Equal(int64(1539500000)))
+			})
+		})
+	})
+
+	ginkgo.Describe("prepare image", func() {
+		ginkgo.It("should return the same ID for the image prepared at different times", func() {
+			rw := &recordingWriter{}
+			p := &Preparer{Writer: rw, filter: *CreateWindowFilter(time.Unix(1539000000, 0),
+				time.Unix(1540000000, 0))}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			p.Preparation(createTestImage(1530000000), &wg)
+			time.Sleep(1100 * time.Millisecond)
+			wg.Add(1)
+			p.Preparation(createTestImage(1530000000), &wg)
+
+			gomega.Expect(rw.records).To(gomega.HaveLen(2))
+			first, second := rw.records[0].(*pb.StorageRecord), rw.records[1].(*pb.StorageRecord)
+			gomega.Expect(first.CreateTime).NotTo(gomega.Equal(second.CreateTime))
+			gomega.Expect(first.RecordID).To(gomega.Equal(second.RecordID))
+			gomega.Expect(first.StartTime.GetSeconds()).To(gomega.Equal(int64(1539000000)))
+			gomega.Expect(first.EndTime.GetSeconds()).To(gomega.Equal(int64(1540000000)))
+		})
+	})
+
+	ginkgo.Describe("prepare image for scheduled windows", func() {
+		prepare := func(from, to int64) *pb.StorageRecord {
+			rw := &recordingWriter{}
+			p := &Preparer{Writer: rw, filter: *CreateWindowFilter(time.Unix(from, 0), time.Unix(to, 0))}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			p.Preparation(createTestImage(1530000000), &wg)
+
+			gomega.Expect(rw.records).To(gomega.HaveLen(1))
+			return rw.records[0].(*pb.StorageRecord)
+		}
+
+		ginkgo.It("should stamp records with the window and derive IDs from it", func() {
+			first := prepare(1539000000, 1539086400)
+			again := prepare(1539000000, 1539086400)
+			next := prepare(1539086400, 1539172800)
+
+			gomega.Expect(first.StartTime.GetSeconds()).To(gomega.Equal(int64(1539000000)))
+			gomega.Expect(first.EndTime.GetSeconds()).To(gomega.Equal(int64(1539086400)))
+			gomega.Expect(next.StartTime.GetSeconds()).To(gomega.Equal(int64(1539086400)))
+			gomega.Expect(next.EndTime.GetSeconds()).To(gomega.Equal(int64(1539172800)))
+			gomega.Expect(again.RecordID).To(gomega.Equal(first.RecordID))
+			gomega.Expect(next.RecordID).NotTo(gomega.Equal(first.RecordID))
+		})
+	})
+
+	ginkgo.Describe("prepare image when writing fails", func() {
+		ginkgo.It("should report the image, so the accounting does not finish without errors", func() {
+			rep, err := report.CreateReporter(constants.PolicySkipAndReport)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			p := &Preparer{Writer: &recordingWriter{err: errors.New("stream closed")}, reporter: rep,
+				filter: *CreateWindowFilter(time.Unix(1539000000, 0), time.Unix(1540000000, 0))}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			p.Preparation(createTestImage(1530000000), &wg)
+
+			gomega.Expect(rep.Skipped()).To(gomega.Equal(1))
+			gomega.Expect(report.ExitCodeOf(rep)).To(gomega.Equal(report.ExitSkipped))
+		})
+	})
+
+	ginkgo.Describe("getRecordID", func() {
+		ginkgo.It("should return the same ID for the same image and window", func() {
+			start := &timestamp.Timestamp{Seconds: 1530000000}
+			end := &timestamp.Timestamp{Seconds: 1540000000}
+
+			gomega.Expect(getRecordID(1, start, end)).To(gomega.Equal(getRecordID(1, start, end)))
+			gomega.Expect(getRecordID(1, start, end)).NotTo(gomega.Equal(getRecordID(2, start, end)))
+			gomega.Expect(getRecordID(1, start, end)).NotTo(gomega.Equal(
+				getRecordID(1, start, &timestamp.Timestamp{Seconds: 1541050000})))
+		})
+	})
 })
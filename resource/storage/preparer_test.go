@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -21,6 +22,8 @@ import (
 	"github.com/sirupsen/logrus/hooks/test"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/writer"
 	"github.com/spf13/viper"
 
 	"google.golang.org/grpc"
@@ -48,10 +51,11 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 		client     *onego.Client
 		vcrRec     *recorder.Recorder
 
-		read *reader.Reader
-		prep *storage.Preparer
-		wg   sync.WaitGroup
-		hook *test.Hook
+		read     *reader.Reader
+		prep     *storage.Preparer
+		reporter *report.Reporter
+		wg       sync.WaitGroup
+		hook     *test.Hook
 	)
 
 	ginkgo.JustBeforeEach(func() {
@@ -104,7 +108,11 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 		viper.SetDefault(constants.CfgOpennebulaTimeout, constants.OpenNebulaTimeout)
 		read = reader.CreateReader(client, rate.NewLimiter(rate.Every(time.Second/time.Duration(30)), 30))
 
-		prep = storage.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), conn)
+		w := writer.CreateWriter(storage.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+		reporter, err = report.CreateReporter(constants.PolicySkipAndReport)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		prep = storage.CreatePreparer(read, w, storage.CreateFilter(), reporter)
 		wg.Add(1)
 	})
 
@@ -125,7 +133,7 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 	})
 
 	ginkgo.Describe("create preparer", func() {
-		ginkgo.Context("when limiter is correct", func() {
+		ginkgo.Context("when writer is correct", func() {
 			ginkgo.BeforeEach(func() {
 				recName = "createOK"
 			})
@@ -134,44 +142,42 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 				gomega.Expect(conn).NotTo(gomega.BeNil())
 				gomega.Expect(read).NotTo(gomega.BeNil())
 
-				p := storage.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), conn)
+				w := writer.CreateWriter(storage.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+				p := storage.CreatePreparer(read, w, storage.CreateFilter(), reporter)
 
 				gomega.Expect(p).NotTo(gomega.BeNil())
 			})
 		})
 
-		ginkgo.Context("when limiter is not correct", func() {
+		ginkgo.Context("when writer is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "limiterNil"
+				recName = "writerNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
-				gomega.Expect(conn).NotTo(gomega.BeNil())
 				gomega.Expect(read).NotTo(gomega.BeNil())
 
-				p := storage.CreatePreparer(read, nil, conn)
+				p := storage.CreatePreparer(read, nil, storage.CreateFilter(), reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepLimiterNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepWriterNil))
 			})
 		})
 
-		ginkgo.Context("when connection is not correct", func() {
+		ginkgo.Context("when filter is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "connectionNil"
+				recName = "filterNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
-				gomega.Expect(read).NotTo(gomega.BeNil())
-
-				p := storage.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), nil)
+				p := storage.CreatePreparer(read, prep.Writer, nil, reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepConnNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepFilterNil))
 			})
 		})
 
@@ -183,7 +189,7 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 			ginkgo.It("should not create preparer", func() {
 				gomega.Expect(conn).NotTo(gomega.BeNil())
 
-				p := storage.CreatePreparer(nil, rate.NewLimiter(rate.Every(1), 1), conn)
+				p := storage.CreatePreparer(nil, prep.Writer, storage.CreateFilter(), reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
@@ -191,6 +197,21 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepReaderNil))
 			})
 		})
+
+		ginkgo.Context("when reporter is not correct", func() {
+			ginkgo.BeforeEach(func() {
+				recName = "writerNil"
+			})
+
+			ginkgo.It("should not create preparer", func() {
+				p := storage.CreatePreparer(read, prep.Writer, storage.CreateFilter(), nil)
+
+				gomega.Expect(p).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepReporterNil))
+			})
+		})
 	})
 
 	ginkgo.Describe("initialize maps for preparer", func() {
@@ -200,7 +221,7 @@ var _ = ginkgo.Describe("Storage Preparer tests", func() {
 			})
 
 			ginkgo.It("should add map with user template identity", func() {
-				prep.InitializeMaps(&wg)
+				prep.InitializeMaps(context.Background(), &wg)
 
 				// TODO map is not visible from this package,
 				//  testing in the same package causes import cycle
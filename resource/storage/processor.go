@@ -1,11 +1,13 @@
 package storage
 
 import (
+	"context"
 	"sync"
 
 	"github.com/goat-project/goat-one/constants"
 
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 
 	"github.com/remeh/sizedwaitgroup"
@@ -15,37 +17,75 @@ import (
 
 // Processor to process storage data.
 type Processor struct {
-	reader reader.Reader
+	reader   reader.Reader
+	reporter *report.Reporter
 }
 
-// CreateProcessor creates Processor to manage reading from OpenNebula.
-func CreateProcessor(r *reader.Reader) *Processor {
+// CreateProcessor creates Processor to manage reading from OpenNebula and reporting of errors.
+func CreateProcessor(r *reader.Reader, rep *report.Reporter) *Processor {
 	if r == nil {
 		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
 		return nil
 	}
 
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
 	return &Processor{
-		reader: *r,
+		reader:   *r,
+		reporter: rep,
 	}
 }
 
-// Process provides listing of the storages once.
-func (p *Processor) Process(read chan resource.Resource, readDone chan bool, swg *sizedwaitgroup.SizedWaitGroup) {
+// Process provides listing of the storages once until the context is canceled. Images are followed by volatile
+// disks, clones of non-persistent images and snapshots of disks of virtual machines in any state except DONE,
+// so disks of powered off, suspended, stopped and undeployed virtual machines are accounted too.
+func (p *Processor) Process(ctx context.Context, read chan resource.Resource, readDone chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
 	defer swg.Done()
 
-	images, err := p.reader.ListAllImages()
+	images, err := p.reader.ListAllImages(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error list images")
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceStorage, "error list images", log.Fields{}, err)
+		}
+
+		return
 	}
 
 	for _, v := range images {
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
 		read <- v
 	}
+
+	vms, err := p.reader.ListAllVirtualMachinesExceptDone(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceStorage, "error list virtual machines", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	for _, vm := range vms {
+		for _, disk := range createVirtualMachineDisks(vm) {
+			if ctx.Err() != nil || p.reporter.Stopped() {
+				return
+			}
+
+			read <- disk
+		}
+	}
 }
 
 // RetrieveInfo - only for VM relevant.
-func (p *Processor) RetrieveInfo(fullInfo chan resource.Resource, wg *sync.WaitGroup, image resource.Resource) {
+func (p *Processor) RetrieveInfo(_ context.Context, fullInfo chan resource.Resource, wg *sync.WaitGroup,
+	image resource.Resource) {
 	defer wg.Done()
 
 	fullInfo <- image
@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/dnaeon/go-vcr/recorder"
 	"github.com/goat-project/goat-one/constants"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/util"
 	"github.com/onego-project/onego"
 	"github.com/onego-project/onego/errors"
@@ -41,6 +43,7 @@ var _ = ginkgo.Describe("Storage Processor tests", func() {
 
 		proc *storage.Processor
 		read *reader.Reader
+		rep  *report.Reporter
 
 		channel chan resource.Resource
 
@@ -74,7 +77,10 @@ var _ = ginkgo.Describe("Storage Processor tests", func() {
 
 		read = reader.CreateReader(client, rate.NewLimiter(rate.Every(time.Second/time.Duration(30)), 30))
 
-		proc = storage.CreateProcessor(read)
+		rep, err = report.CreateReporter(constants.PolicyFailFast)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		proc = storage.CreateProcessor(read, rep)
 
 		channel = make(chan resource.Resource)
 	})
@@ -89,18 +95,27 @@ var _ = ginkgo.Describe("Storage Processor tests", func() {
 	ginkgo.Describe("create processor", func() {
 		ginkgo.Context("when read is correct", func() {
 			ginkgo.It("should create processor", func() {
-				gomega.Expect(storage.CreateProcessor(read)).NotTo(gomega.BeNil())
+				gomega.Expect(storage.CreateProcessor(read, rep)).NotTo(gomega.BeNil())
 			})
 		})
 
 		ginkgo.Context("when reader is not correct", func() {
 			ginkgo.It("should not create processor", func() {
-				gomega.Expect(storage.CreateProcessor(nil)).To(gomega.BeNil())
+				gomega.Expect(storage.CreateProcessor(nil, rep)).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
 				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReaderNil))
 			})
 		})
+
+		ginkgo.Context("when reporter is not correct", func() {
+			ginkgo.It("should not create processor", func() {
+				gomega.Expect(storage.CreateProcessor(read, nil)).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReporterNil))
+			})
+		})
 	})
 
 	ginkgo.Describe("process", func() {
@@ -110,7 +125,7 @@ var _ = ginkgo.Describe("Storage Processor tests", func() {
 			})
 
 			ginkgo.It("should post resource to the channel", func(done ginkgo.Done) {
-				go proc.Process(channel, nil, &swg)
+				go proc.Process(context.Background(), channel, nil, &swg)
 
 				gomega.Expect((<-channel).ID()).To(gomega.Equal(0))
 
@@ -125,7 +140,7 @@ var _ = ginkgo.Describe("Storage Processor tests", func() {
 				var wg sync.WaitGroup
 				wg.Add(1)
 
-				go proc.RetrieveInfo(channel, &wg, resources.CreateImageWithID(0))
+				go proc.RetrieveInfo(context.Background(), channel, &wg, resources.CreateImageWithID(0))
 
 				gomega.Expect((<-channel).ID()).To(gomega.Equal(0))
 
@@ -3,7 +3,9 @@ package reader
 import (
 	"context"
 
+	"github.com/beevik/etree"
 	"github.com/goat-project/goat-one/resource"
+	"github.com/goat-project/goat-one/rpc"
 
 	"github.com/onego-project/onego/services"
 
@@ -14,6 +16,12 @@ import (
 type Reader struct {
 }
 
+// DatastoresReader structure for a Reader which read datastores with their capacity.
+type DatastoresReader struct {
+}
+
+const datastoresMethod = "one.datastorepool.info"
+
 // ReadResources reads an array of images.
 func (ir *Reader) ReadResources(ctx context.Context, client *onego.Client) ([]resource.Resource, error) {
 	objs, err := client.ImageService.ListAll(ctx, services.OwnershipFilterAll)
@@ -28,3 +36,13 @@ func (ir *Reader) ReadResources(ctx context.Context, client *onego.Client) ([]re
 
 	return res, err
 }
+
+// ReadRPC reads all datastores in one call.
+func (dr *DatastoresReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, datastoresMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.SelectElements("DATASTORE"), nil
+}
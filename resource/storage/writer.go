@@ -2,19 +2,19 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/goat-project/goat-one/writer"
 
 	"github.com/golang/protobuf/ptypes/empty"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 	"github.com/spf13/viper"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 
 	pb "github.com/goat-project/goat-proto-go"
-
-	log "github.com/sirupsen/logrus"
 )
 
 // Writer structure to write storage data to Goat server.
@@ -31,40 +31,69 @@ func CreateWriter(limiter *rate.Limiter) *Writer {
 }
 
 // SetUp creates gRPC client and sets up Stream to process storages to Writer.
-func (w *Writer) SetUp(conn *grpc.ClientConn) {
+func (w *Writer) SetUp(conn *grpc.ClientConn) error {
 	// create gRPC client
 	grpcClient := pb.NewAccountingServiceClient(conn)
 
 	// create Stream to process VMs
 	stream, err := grpcClient.ProcessStorages(context.Background())
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error create gRPC client stream")
+		return err
 	}
 
 	w.Stream = stream
+
+	return nil
 }
 
-// Write writes network record to Goat server.
+// Write writes storage record to Goat server.
 func (w *Writer) Write(record writer.Record) error {
+	defer metrics.ObserveSend(constants.ResourceStorage, time.Now())
+
+	return w.Send(w.WrapRecord(record))
+}
+
+// SendIdentifier sends identifier to Goat server.
+func (w *Writer) SendIdentifier() error {
+	return w.Send(w.WrapIdentifier())
+}
+
+// Send sends data wrapped by WrapRecord or WrapIdentifier to Goat server. It waits for the rate limit of writes
+// and returns error when the stream is canceled meanwhile.
+func (w *Writer) Send(data writer.Record) error {
+	if err := w.rateLimiter.Wait(w.Stream.Context()); err != nil {
+		return err
+	}
+
+	return w.Stream.Send(data.(*pb.StorageData))
+}
+
+// CreateRecord creates empty record, e.g. to validate rules for fields of the records.
+func (w *Writer) CreateRecord() writer.Record {
+	return &pb.StorageRecord{}
+}
+
+// CreateData creates empty data accepted by Goat server, e.g. to read spooled data into.
+func (w *Writer) CreateData() writer.Record {
+	return &pb.StorageData{}
+}
+
+// WrapRecord wraps storage record to data accepted by Goat server.
+func (w *Writer) WrapRecord(record writer.Record) writer.Record {
 	rec := record.(*pb.StorageRecord)
 
-	storageData := &pb.StorageData{
+	return &pb.StorageData{
 		Data: &pb.StorageData_Storage{
 			Storage: rec,
 		},
 	}
-
-	return w.Stream.Send(storageData)
 }
 
-// SendIdentifier sends identifier to Goat server.
-func (w *Writer) SendIdentifier() error {
-	storageDataIdentifier := pb.StorageData_Identifier{Identifier: viper.GetString(constants.CfgIdentifier)}
-	data := &pb.StorageData{
-		Data: &storageDataIdentifier,
+// WrapIdentifier wraps identifier to data accepted by Goat server.
+func (w *Writer) WrapIdentifier() writer.Record {
+	return &pb.StorageData{
+		Data: &pb.StorageData_Identifier{Identifier: viper.GetString(constants.CfgIdentifier)},
 	}
-
-	return w.Stream.Send(data)
 }
 
 // Close gets to know to the goat server that a writing is finished and a response is expected.
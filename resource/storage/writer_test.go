@@ -1,18 +1,40 @@
 package storage_test
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"cloud.google.com/go/rpcreplay"
 	"github.com/goat-project/goat-one/resource/storage"
 	goat_grpc "github.com/goat-project/goat-proto-go"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 )
 
+type countingStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	sent int
+}
+
+func (s *countingStream) Send(*goat_grpc.StorageData) error {
+	s.sent++
+	return nil
+}
+
+func (s *countingStream) CloseAndRecv() (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+func (s *countingStream) Context() context.Context {
+	return s.ctx
+}
+
 var recWriterDir = "records/writer/"
 
 var _ = ginkgo.Describe("Storage Writer tests", func() {
@@ -108,3 +130,35 @@ var _ = ginkgo.Describe("Storage Writer tests", func() {
 		})
 	})
 })
+
+var _ = ginkgo.Describe("Storage Writer rate limit tests", func() {
+	ginkgo.Context("when records are written faster than the rate limit", func() {
+		ginkgo.It("should wait for the rate limit", func() {
+			stream := &countingStream{ctx: context.Background()}
+			w := storage.CreateWriter(rate.NewLimiter(rate.Every(100*time.Millisecond), 1))
+			w.Stream = stream
+
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				gomega.Expect(w.Write(&goat_grpc.StorageRecord{})).NotTo(gomega.HaveOccurred())
+			}
+
+			gomega.Expect(time.Since(start)).To(gomega.BeNumerically(">=", 200*time.Millisecond))
+			gomega.Expect(stream.sent).To(gomega.Equal(3))
+		})
+	})
+
+	ginkgo.Context("when stream is canceled", func() {
+		ginkgo.It("should not send record", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			stream := &countingStream{ctx: ctx}
+			w := storage.CreateWriter(rate.NewLimiter(rate.Every(100*time.Millisecond), 1))
+			w.Stream = stream
+
+			gomega.Expect(w.Write(&goat_grpc.StorageRecord{})).To(gomega.HaveOccurred())
+			gomega.Expect(stream.sent).To(gomega.BeZero())
+		})
+	})
+})
@@ -0,0 +1,196 @@
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+
+	"github.com/onego-project/onego/resources"
+	"github.com/remeh/sizedwaitgroup"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AccountedVirtualMachine represents a virtual machine with its history records from OpenNebula accounting.
+type AccountedVirtualMachine struct {
+	*resources.VirtualMachine
+	History []*History
+}
+
+// History represents a period a virtual machine was running on a host. Start and End are clipped
+// to the filter window.
+type History struct {
+	Seq    int
+	HostID int
+	Start  time.Time
+	End    time.Time
+}
+
+// AccountingProcessor to process virtual machine data from OpenNebula accounting.
+type AccountingProcessor struct {
+	reader   reader.Reader
+	filter   Filter
+	reporter *report.Reporter
+}
+
+// CreateAccountingProcessor creates processor with reader, filter which window is used for accounting
+// and reporter of errors.
+func CreateAccountingProcessor(r *reader.Reader, f *Filter, rep *report.Reporter) *AccountingProcessor {
+	if r == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
+		return nil
+	}
+
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcFilterNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
+	return &AccountingProcessor{
+		reader:   *r,
+		filter:   *f,
+		reporter: rep,
+	}
+}
+
+// Process lists history records for the filter window in one call and writes virtual machines
+// with their history records to the read channel until the context is canceled.
+func (p *AccountingProcessor) Process(ctx context.Context, read chan resource.Resource, readDone chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
+	defer swg.Done()
+
+	records, err := p.reader.ListVirtualMachineAccounting(ctx, p.filter.recordsFrom, p.filter.recordsTo)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceVM, "error list virtual machine accounting", log.Fields{}, err)
+		}
+
+		return
+	}
+
+	for _, vm := range createAccountedVirtualMachines(records, &p.filter, time.Now()) {
+		if ctx.Err() != nil || p.reporter.Stopped() {
+			return
+		}
+
+		read <- vm
+	}
+}
+
+// RetrieveInfo passes virtual machine since OpenNebula accounting contains virtual machine info.
+func (p *AccountingProcessor) RetrieveInfo(_ context.Context, fullInfo chan resource.Resource,
+	wg *sync.WaitGroup, vm resource.Resource) {
+	defer wg.Done()
+
+	fullInfo <- vm
+}
+
+// createAccountedVirtualMachines groups history records by virtual machine in the order they were listed.
+// Virtual machines which were not running in the filter window are omitted.
+func createAccountedVirtualMachines(records []*etree.Element, f *Filter, now time.Time) []*AccountedVirtualMachine {
+	var vms []*AccountedVirtualMachine
+	byID := map[int]*AccountedVirtualMachine{}
+
+	for _, record := range records {
+		id, err := childInt(record, "OID")
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get virtual machine id from history record")
+			continue
+		}
+
+		history, err := createHistory(record, f, now)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "id": id}).Error("error get history record")
+			continue
+		}
+
+		if history == nil {
+			continue
+		}
+
+		vm, ok := byID[id]
+		if !ok {
+			data := record.SelectElement("VM")
+			if data == nil {
+				log.WithFields(log.Fields{"id": id}).Error("no virtual machine in history record")
+				continue
+			}
+
+			vm = &AccountedVirtualMachine{VirtualMachine: resources.CreateVirtualMachineFromXML(data)}
+			byID[id] = vm
+			vms = append(vms, vm)
+		}
+
+		vm.History = append(vm.History, history)
+	}
+
+	return vms
+}
+
+// createHistory returns history record clipped to the filter window or nil when the virtual machine
+// was not running in the window.
+func createHistory(record *etree.Element, f *Filter, now time.Time) (*History, error) {
+	seq, err := childInt(record, "SEQ")
+	if err != nil {
+		return nil, err
+	}
+
+	hostID, err := childInt(record, "HID")
+	if err != nil {
+		return nil, err
+	}
+
+	rsTime, err := childInt(record, "RSTIME")
+	if err != nil {
+		return nil, err
+	}
+
+	reTime, err := childInt(record, "RETIME")
+	if err != nil {
+		return nil, err
+	}
+
+	if rsTime == 0 {
+		return nil, nil
+	}
+
+	end := now
+	if reTime != 0 {
+		end = time.Unix(int64(reTime), 0)
+	}
+
+	start, end, ok := f.clip(time.Unix(int64(rsTime), 0), end)
+	if !ok {
+		return nil, nil
+	}
+
+	return &History{
+		Seq:    seq,
+		HostID: hostID,
+		Start:  start,
+		End:    end,
+	}, nil
+}
+
+func childInt(e *etree.Element, tag string) (int, error) {
+	child := e.SelectElement(tag)
+	if child == nil {
+		return 0, fmt.Errorf("no %s element", tag)
+	}
+
+	return strconv.Atoi(child.Text())
+}
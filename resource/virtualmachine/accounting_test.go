@@ -0,0 +1,136 @@
+package virtualmachine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Virtual machine accounting tests", func() {
+	var (
+		records []*etree.Element
+		filter  *Filter
+		now     time.Time
+	)
+
+	ginkgo.JustBeforeEach(func() {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/accounting.xml")).NotTo(gomega.HaveOccurred())
+
+		records = doc.Root().SelectElements("HISTORY")
+	})
+
+	ginkgo.BeforeEach(func() {
+		filter = &Filter{
+			recordsFrom: time.Unix(1540950000, 0),
+			recordsTo:   time.Unix(1541050000, 0),
+		}
+		now = time.Unix(1541100000, 0)
+	})
+
+	ginkgo.Describe("create processor", func() {
+		ginkgo.Context("when reader is nil", func() {
+			ginkgo.It("should not create processor", func() {
+				rep, err := report.CreateReporter(constants.PolicyFailFast)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(CreateAccountingProcessor(nil, filter, rep)).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Describe("create accounted virtual machines", func() {
+		ginkgo.Context("when history records overlap the window", func() {
+			ginkgo.It("should group clipped history records by virtual machine", func() {
+				vms := createAccountedVirtualMachines(records, filter, now)
+
+				gomega.Expect(vms).To(gomega.HaveLen(1))
+				gomega.Expect(vms[0].ID()).To(gomega.Equal(57502))
+				gomega.Expect(vms[0].History).To(gomega.Equal([]*History{
+					{Seq: 0, HostID: 932, Start: time.Unix(1540950000, 0), End: time.Unix(1541000000, 0)},
+					{Seq: 1, HostID: 933, Start: time.Unix(1541000100, 0), End: time.Unix(1541050000, 0)},
+				}))
+			})
+		})
+
+		ginkgo.Context("when virtual machine is still running", func() {
+			ginkgo.It("should clip history record to current time", func() {
+				filter.recordsFrom = time.Unix(1541200000, 0)
+				filter.recordsTo = time.Unix(1541300000, 0)
+				now = time.Unix(1541250000, 0)
+
+				vms := createAccountedVirtualMachines(records, filter, now)
+
+				gomega.Expect(vms).To(gomega.HaveLen(1))
+				gomega.Expect(vms[0].History).To(gomega.Equal([]*History{
+					{Seq: 1, HostID: 933, Start: time.Unix(1541200000, 0), End: now},
+				}))
+			})
+		})
+
+		ginkgo.Context("when history records are out of the window", func() {
+			ginkgo.It("should not create any virtual machine", func() {
+				filter.recordsFrom = time.Unix(1530000000, 0)
+				filter.recordsTo = time.Unix(1540000000, 0)
+
+				gomega.Expect(createAccountedVirtualMachines(records, filter, now)).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when history record is not complete", func() {
+			ginkgo.It("should skip the history record", func() {
+				records[0].RemoveChild(records[0].SelectElement("RSTIME"))
+
+				vms := createAccountedVirtualMachines(records, filter, now)
+
+				gomega.Expect(vms).To(gomega.HaveLen(1))
+				gomega.Expect(vms[0].History).To(gomega.HaveLen(1))
+				gomega.Expect(vms[0].History[0].Seq).To(gomega.Equal(1))
+			})
+		})
+	})
+
+	ginkgo.Describe("filter accounted virtual machine", func() {
+		ginkgo.It("should post vm to the channel", func(done ginkgo.Done) {
+			var wg sync.WaitGroup
+
+			vm := createAccountedVirtualMachines(records, filter, now)[0]
+			filtered := make(chan resource.Resource)
+
+			wg.Add(1)
+			go filter.Filtering(vm, filtered, &wg)
+
+			gomega.Expect(<-filtered).To(gomega.Equal(vm))
+
+			close(done)
+		}, 0.2)
+	})
+
+	ginkgo.Describe("getWindowUsage", func() {
+		ginkgo.Context("when virtual machine was running in the window", func() {
+			ginkgo.It("should return usage bounded by the window", func() {
+				preparer := &Preparer{
+					filter:                     *filter,
+					hostTemplateBenchmarkType:  map[int]string{932: "HEP-SPEC06", 933: "other"},
+					hostTemplateBenchmarkValue: map[int]string{932: "100", 933: "50"},
+				}
+
+				vm := createAccountedVirtualMachines(records, filter, now)[0]
+
+				u, err := getWindowUsage(preparer, 57502, vm.VirtualMachine, vm.History)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(u.startTime.GetSeconds()).To(gomega.Equal(int64(1540950000)))
+				gomega.Expect(u.endTime.GetSeconds()).To(gomega.Equal(int64(1541050000)))
+				gomega.Expect(u.wallDuration.GetSeconds()).To(gomega.Equal(int64(99900)))
+				gomega.Expect(u.benchmarkType.GetValue()).To(gomega.Equal("HEP-SPEC06"))
+				gomega.Expect(u.benchmark.GetValue()).To(gomega.Equal(float32(100)))
+			})
+		})
+	})
+})
@@ -0,0 +1,477 @@
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/util"
+	"github.com/goat-project/goat-one/writer"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/onego-project/onego/resources"
+	"github.com/spf13/viper"
+
+	pb "github.com/goat-project/goat-proto-go"
+)
+
+// Explainer explains how virtual machine records are derived from OpenNebula data.
+type Explainer struct {
+	reader    reader.Reader
+	preparer  *Preparer
+	collector *collector
+	users     map[int]*resources.User
+	images    map[int]*resources.Image
+	hosts     map[int]*resources.Host
+	clusters  map[int]*resources.Cluster
+}
+
+// Explanation represents virtual machine record with the source of every field.
+type Explanation struct {
+	Record *pb.VmRecord
+	Fields []Field
+}
+
+// Field represents value of a virtual machine record field and the source it was derived from.
+type Field struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// collector collects records prepared by the preparer instead of writing them.
+type collector struct {
+	mu      sync.Mutex
+	records []*pb.VmRecord
+}
+
+// CreateExplainer creates Explainer with reader and filter. The records are prepared as by accounting
+// with the settings of virtual machines set by configuration, errors of preparation are only logged.
+func CreateExplainer(r *reader.Reader, f *Filter) *Explainer {
+	c := &collector{}
+
+	rep, err := report.CreateReporter(constants.PolicySkipAndReport)
+	if err != nil {
+		return nil
+	}
+
+	p := CreatePreparer(r, c, f, rep)
+	if p == nil {
+		return nil
+	}
+
+	return &Explainer{
+		reader:    *r,
+		preparer:  p,
+		collector: c,
+	}
+}
+
+// Explain retrieves a virtual machine with a given ID, prepares its records and explains every field.
+func (e *Explainer) Explain(ctx context.Context, id int) ([]*Explanation, error) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	e.preparer.InitializeMaps(ctx, &wg)
+	wg.Wait()
+
+	if err := e.readSources(ctx); err != nil {
+		return nil, err
+	}
+
+	vm, err := e.reader.RetrieveVirtualMachineInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.explain(vm)
+}
+
+// readSources reads users, images, hosts and clusters the fields are derived from.
+func (e *Explainer) readSources(ctx context.Context) error {
+	users, err := e.reader.ListAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	images, err := e.reader.ListAllImages(ctx)
+	if err != nil {
+		return err
+	}
+
+	hosts, err := e.reader.ListAllHosts(ctx)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := e.reader.ListAllClusters(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.users = make(map[int]*resources.User, len(users))
+	for _, u := range users {
+		if id, err := u.ID(); err == nil {
+			e.users[id] = u
+		}
+	}
+
+	e.images = make(map[int]*resources.Image, len(images))
+	for _, i := range images {
+		if id, err := i.ID(); err == nil {
+			e.images[id] = i
+		}
+	}
+
+	e.hosts = make(map[int]*resources.Host, len(hosts))
+	for _, h := range hosts {
+		if id, err := h.ID(); err == nil {
+			e.hosts[id] = h
+		}
+	}
+
+	e.clusters = make(map[int]*resources.Cluster, len(clusters))
+	for _, c := range clusters {
+		if id, err := c.ID(); err == nil {
+			e.clusters[id] = c
+		}
+	}
+
+	return nil
+}
+
+// explain prepares records of a virtual machine and explains every field of them.
+func (e *Explainer) explain(vm *resources.VirtualMachine) ([]*Explanation, error) {
+	e.collector.records = nil
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	e.preparer.Preparation(vm, &wg)
+
+	if len(e.collector.records) == 0 {
+		return nil, fmt.Errorf("no record prepared for virtual machine, see log for the reason")
+	}
+
+	id, _ := vm.ID()
+
+	var history []*History
+	if e.preparer.recordPerHistory || e.preparer.clipToWindow {
+		history = getHistory(e.preparer, vm)
+	}
+
+	explanations := make([]*Explanation, 0, len(e.collector.records))
+	for i, rec := range e.collector.records {
+		var segments []*History
+		switch {
+		case e.preparer.recordPerHistory && i < len(history):
+			segments = []*History{history[i]}
+		case e.preparer.clipToWindow:
+			segments = history
+		}
+
+		explanations = append(explanations, &Explanation{
+			Record: rec,
+			Fields: e.fields(id, vm, rec, segments),
+		})
+	}
+
+	return explanations, nil
+}
+
+func (e *Explainer) fields(id int, vm *resources.VirtualMachine, rec *pb.VmRecord, segments []*History) []Field {
+	uid, _ := vm.User()
+	hostID, hostOK := e.benchmarkHost(vm, segments)
+
+	benchmarkTypeSource, benchmarkSource := "no history record, no host", "no history record, no host"
+	if hostOK {
+		benchmarkTypeSource = e.benchmarkSource(hostID, constants.TemplateBenchmarkType)
+		benchmarkSource = e.benchmarkSource(hostID, constants.TemplateBenchmarkValue)
+	}
+
+	return []Field{
+		{"VmUuid", rec.VmUuid, e.recordIDSource(id, segments)},
+		{"SiteName", rec.SiteName, "configuration " + constants.CfgSiteName},
+		{"CloudComputeService", format(rec.CloudComputeService),
+			"configuration " + constants.CfgCloudComputeService},
+		{"MachineName", rec.MachineName, "DEPLOY_ID"},
+		{"LocalUserId", format(rec.LocalUserId), "UID"},
+		{"LocalGroupId", format(rec.LocalGroupId), "GID"},
+		{"GlobalUserName", format(rec.GlobalUserName), e.userSource(uid)},
+		{"Fqan", format(rec.Fqan), "/GNAME/Role=NULL/Capability=NULL"},
+		{"Status", format(rec.Status), "STATE"},
+		{"StartTime", format(rec.StartTime), e.timeSource("STIME", "RSTIME", segments)},
+		{"EndTime", format(rec.EndTime), e.timeSource("ETIME", "RETIME", segments)},
+		{"SuspendDuration", format(rec.SuspendDuration), "EndTime - StartTime - WallDuration"},
+		{"WallDuration", format(rec.WallDuration), e.wallDurationSource(vm, segments)},
+		{"CpuDuration", format(rec.CpuDuration), e.cpuDurationSource()},
+		{"CpuCount", format(rec.CpuCount), "TEMPLATE/VCPU"},
+		{"NetworkType", format(rec.NetworkType), "not accounted"},
+		{"NetworkInbound", format(rec.NetworkInbound), "MONITORING/NETTX"},
+		{"NetworkOutbound", format(rec.NetworkOutbound), "MONITORING/NETRX"},
+		{"PublicIpCount", format(rec.PublicIpCount), publicIPSource(vm)},
+		{"Memory", format(rec.Memory), "TEMPLATE/MEMORY"},
+		{"Disk", format(rec.Disk), diskSource(vm)},
+		{"BenchmarkType", format(rec.BenchmarkType), benchmarkTypeSource},
+		{"Benchmark", format(rec.Benchmark), benchmarkSource},
+		{"StorageRecordId", format(rec.StorageRecordId), "not accounted"},
+		{"ImageId", format(rec.ImageId), e.imageSource(vm)},
+		{"CloudType", format(rec.CloudType), "configuration " + constants.CfgCloudType},
+	}
+}
+
+func (e *Explainer) recordIDSource(id int, segments []*History) string {
+	endpoint := viper.GetString(constants.CfgOpennebulaEndpoint)
+	if e.preparer.recordPerHistory && len(segments) == 1 {
+		return fmt.Sprintf("OpenNebula endpoint %s, virtual machine %d, history record SEQ %d", endpoint, id,
+			segments[0].Seq)
+	}
+
+	return fmt.Sprintf("OpenNebula endpoint %s, virtual machine %d", endpoint, id)
+}
+
+func (e *Explainer) timeSource(vmTime, historyTime string, segments []*History) string {
+	switch {
+	case e.preparer.recordPerHistory && len(segments) == 1:
+		source := fmt.Sprintf("history record SEQ %d %s", segments[0].Seq, historyTime)
+		if e.preparer.clipToWindow {
+			source += " " + e.window()
+		}
+
+		return source
+	case e.preparer.clipToWindow:
+		return vmTime + " " + e.window()
+	default:
+		return vmTime
+	}
+}
+
+func (e *Explainer) window() string {
+	from, to := e.preparer.filter.Window()
+
+	return fmt.Sprintf("clipped to window %s - %s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+}
+
+// wallDurationSource lists history records the wall duration is summed from. The segments are given by the same
+// function as in preparation, so the running segment is listed too.
+func (e *Explainer) wallDurationSource(vm *resources.VirtualMachine, segments []*History) string {
+	if e.preparer.recordPerHistory || e.preparer.clipToWindow {
+		if len(segments) == 0 {
+			return "no history record in window"
+		}
+	} else {
+		var err error
+		if segments, err = historySegments(vm, time.Now()); err != nil || len(segments) == 0 {
+			return "no history record"
+		}
+	}
+
+	parts := make([]string, 0, len(segments))
+	for _, h := range segments {
+		parts = append(parts, segment(h.Seq, h.HostID, h.Start, h.End))
+	}
+
+	return strings.Join(parts, " + ")
+}
+
+func segment(seq, hostID int, start, end time.Time) string {
+	return fmt.Sprintf("SEQ %d on host %d %s - %s (%ds)", seq, hostID, start.UTC().Format(time.RFC3339),
+		end.UTC().Format(time.RFC3339), end.Unix()-start.Unix())
+}
+
+func (e *Explainer) cpuDurationSource() string {
+	switch e.preparer.cpuDuration {
+	case constants.CPUDurationWallVCPU:
+		return "WallDuration * TEMPLATE/VCPU"
+	case constants.CPUDurationMonitored:
+		return "WallDuration * mean CPU usage of monitoring records in the period / 100"
+	default:
+		return "WallDuration"
+	}
+}
+
+// benchmarkHost returns the host the benchmark is taken from, i.e. the host of the first history record.
+func (e *Explainer) benchmarkHost(vm *resources.VirtualMachine, segments []*History) (int, bool) {
+	if e.preparer.recordPerHistory || e.preparer.clipToWindow {
+		if len(segments) == 0 {
+			return 0, false
+		}
+
+		return segments[0].HostID, true
+	}
+
+	historyRecords, err := vm.HistoryRecords()
+	if err != nil || len(historyRecords) == 0 || historyRecords[0].HID == nil {
+		return 0, false
+	}
+
+	return *historyRecords[0].HID, true
+}
+
+// benchmarkSource explains the benchmark of a host, it is taken from the cluster of the host when the host
+// has no benchmark in its template.
+func (e *Explainer) benchmarkSource(hostID int, attribute string) string {
+	host := e.hosts[hostID]
+	if host == nil {
+		return fmt.Sprintf("host %d not found", hostID)
+	}
+
+	if _, err := host.Attribute(attribute); err == nil {
+		return fmt.Sprintf("host %d %s", hostID, attribute)
+	}
+
+	clusterID, err := host.Cluster()
+	if err != nil {
+		return fmt.Sprintf("host %d has no %s and no cluster", hostID, attribute)
+	}
+
+	if cluster := e.clusters[clusterID]; cluster != nil {
+		if _, err = cluster.Attribute(attribute); err == nil {
+			return fmt.Sprintf("cluster %d %s, host %d has none", clusterID, attribute, hostID)
+		}
+	}
+
+	return fmt.Sprintf("neither host %d nor cluster %d has %s", hostID, clusterID, attribute)
+}
+
+func (e *Explainer) imageSource(vm *resources.VirtualMachine) string {
+	disks, err := vm.Disks()
+	if err != nil || len(disks) == 0 || disks[0] == nil {
+		return "no disk"
+	}
+
+	imageID := disks[0].ImageID
+
+	image, ok := e.images[imageID]
+	if !ok {
+		return fmt.Sprintf("disk %d: image %d not found", disks[0].DiskID, imageID)
+	}
+
+	return fmt.Sprintf("disk %d: ", disks[0].DiskID) + templateSource("image", imageID, image,
+		constants.TemplateCloudkeeperApplianceMpuri)
+}
+
+func (e *Explainer) userSource(uid int) string {
+	user, ok := e.users[uid]
+	if !ok {
+		return fmt.Sprintf("user %d not found", uid)
+	}
+
+	return templateSource("user", uid, user, constants.TemplateIdentity)
+}
+
+// templateSource explains value taken from a template of a resource, ID of the resource is used when the template
+// has no such attribute.
+func templateSource(name string, id int, res interface{ Attribute(string) (string, error) },
+	attribute string) string {
+	if _, err := res.Attribute(attribute); err != nil {
+		return fmt.Sprintf("%s %d ID, %s is not set", name, id, attribute)
+	}
+
+	return fmt.Sprintf("%s %d %s", name, id, attribute)
+}
+
+// publicIPSource lists NICs with public IPv4 or global IPv6 address.
+func publicIPSource(vm *resources.VirtualMachine) string {
+	nics, err := vm.NICs()
+	if err != nil || len(nics) == 0 {
+		return "no NIC"
+	}
+
+	parts := make([]string, 0, len(nics))
+	for _, nic := range nics {
+		public := "private"
+		if util.IsPublicIPv4(nic.IP) || nic.IP6Global != nil {
+			public = "public"
+		}
+
+		address := nic.IP.String()
+		if nic.IP6Global != nil {
+			address += " " + nic.IP6Global.String()
+		}
+
+		parts = append(parts, fmt.Sprintf("NIC %d %s %s", nic.NicID, address, public))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// diskSource lists sizes of disks.
+func diskSource(vm *resources.VirtualMachine) string {
+	disks, err := vm.Disks()
+	if err != nil || len(disks) == 0 {
+		return "no disk"
+	}
+
+	parts := make([]string, 0, len(disks))
+	for _, disk := range disks {
+		parts = append(parts, fmt.Sprintf("DISK %d %d MB", disk.DiskID, disk.Size))
+	}
+
+	return strings.Join(parts, " + ")
+}
+
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case *wrappers.StringValue:
+		if v != nil {
+			return v.GetValue()
+		}
+	case *wrappers.UInt64Value:
+		if v != nil {
+			return fmt.Sprint(v.GetValue())
+		}
+	case *wrappers.FloatValue:
+		if v != nil {
+			return fmt.Sprint(v.GetValue())
+		}
+	case *timestamp.Timestamp:
+		if v != nil {
+			return time.Unix(v.GetSeconds(), 0).UTC().Format(time.RFC3339)
+		}
+	case *duration.Duration:
+		if v != nil {
+			return fmt.Sprintf("%ds", v.GetSeconds())
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+
+	return "-"
+}
+
+// Write writes the explanation as a table of fields with their values and sources.
+func (e *Explanation) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "FIELD\tVALUE\tSOURCE")
+	for _, f := range e.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name, f.Value, f.Source)
+	}
+
+	return tw.Flush()
+}
+
+// Write collects record.
+func (c *collector) Write(rec writer.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = append(c.records, rec.(*pb.VmRecord))
+
+	return nil
+}
+
+// SendIdentifier does nothing, records are not sent.
+func (c *collector) SendIdentifier() error {
+	return nil
+}
+
+// Finish does nothing, records are not sent.
+func (c *collector) Finish() {}
@@ -0,0 +1,137 @@
+package virtualmachine
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = ginkgo.Describe("Explainer tests", func() {
+	var (
+		doc       *etree.Document
+		explainer *Explainer
+	)
+
+	element := func(xml string) *etree.Element {
+		d := etree.NewDocument()
+		gomega.Expect(d.ReadFromString(xml)).To(gomega.Succeed())
+
+		return d.Root()
+	}
+
+	source := func(explanation *Explanation, name string) string {
+		for _, f := range explanation.Fields {
+			if f.Name == name {
+				return f.Source
+			}
+		}
+
+		return ""
+	}
+
+	ginkgo.BeforeEach(func() {
+		doc = etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/vm.xml")).To(gomega.Succeed())
+
+		viper.SetDefault(constants.CfgSiteName, "test-site")
+		viper.SetDefault(constants.CfgCloudType, "test-cloud")
+
+		rep, err := report.CreateReporter(constants.PolicySkipAndReport)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		c := &collector{}
+		explainer = &Explainer{
+			preparer: &Preparer{
+				Writer:                     c,
+				reporter:                   rep,
+				userTemplateIdentity:       map[int]string{46: "world"},
+				hostTemplateBenchmarkType:  map[int]string{932: "HEP-SPEC06"},
+				hostTemplateBenchmarkValue: map[int]string{932: "10.5"},
+			},
+			collector: c,
+			users: map[int]*resources.User{
+				46: resources.CreateUserFromXML(element(
+					"<USER><ID>46</ID><TEMPLATE><IDENTITY>world</IDENTITY></TEMPLATE></USER>")),
+			},
+			hosts: map[int]*resources.Host{
+				932: resources.CreateHostFromXML(element("<HOST><ID>932</ID><CLUSTER_ID>117</CLUSTER_ID></HOST>")),
+			},
+			clusters: map[int]*resources.Cluster{
+				117: resources.CreateClusterFromXML(element("<CLUSTER><ID>117</ID><TEMPLATE>" +
+					"<BENCHMARK_TYPE>HEP-SPEC06</BENCHMARK_TYPE><BENCHMARK_VALUE>10.5</BENCHMARK_VALUE>" +
+					"</TEMPLATE></CLUSTER>")),
+			},
+		}
+	})
+
+	ginkgo.Describe("explain virtual machine", func() {
+		ginkgo.Context("when record is prepared", func() {
+			ginkgo.It("should explain every field of the record", func() {
+				explanations, err := explainer.explain(resources.CreateVirtualMachineFromXML(doc.Root()))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(explanations).To(gomega.HaveLen(1))
+
+				explanation := explanations[0]
+				gomega.Expect(explanation.Record.GlobalUserName.GetValue()).To(gomega.Equal("world"))
+				gomega.Expect(source(explanation, "GlobalUserName")).To(gomega.Equal("user 46 TEMPLATE/IDENTITY"))
+				gomega.Expect(source(explanation, "Benchmark")).To(gomega.Equal(
+					"cluster 117 TEMPLATE/BENCHMARK_VALUE, host 932 has none"))
+				gomega.Expect(source(explanation, "WallDuration")).To(gomega.ContainSubstring("SEQ 0 on host 932"))
+				gomega.Expect(source(explanation, "PublicIpCount")).To(gomega.ContainSubstring(
+					"NIC 0 123.123.123.85 public"))
+			})
+		})
+
+		ginkgo.Context("when virtual machine is running", func() {
+			ginkgo.It("should explain the running segment counted in the wall duration", func() {
+				explanations, err := explainer.explain(resources.CreateVirtualMachineFromXML(doc.Root()))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(source(explanations[0], "WallDuration")).To(gomega.ContainSubstring(
+					"SEQ 1 on host 932 2018-05-21T16:14:05Z"))
+			})
+		})
+
+		ginkgo.Context("when user has no identity", func() {
+			ginkgo.It("should explain that user ID is used", func() {
+				explainer.users[46] = resources.CreateUserFromXML(element("<USER><ID>46</ID><TEMPLATE/></USER>"))
+
+				explanations, err := explainer.explain(resources.CreateVirtualMachineFromXML(doc.Root()))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(source(explanations[0], "GlobalUserName")).To(gomega.Equal(
+					"user 46 ID, TEMPLATE/IDENTITY is not set"))
+			})
+		})
+
+		ginkgo.Context("when record is not prepared", func() {
+			ginkgo.It("should return error", func() {
+				_, err := explainer.explain(resources.CreateVirtualMachineFromXML(element("<VM><ID>1</ID></VM>")))
+
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("write explanation", func() {
+		ginkgo.It("should write a row for every field", func() {
+			explanations, err := explainer.explain(resources.CreateVirtualMachineFromXML(doc.Root()))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			var buf bytes.Buffer
+			gomega.Expect(explanations[0].Write(&buf)).To(gomega.Succeed())
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			gomega.Expect(lines).To(gomega.HaveLen(len(explanations[0].Fields) + 1))
+			gomega.Expect(strings.Fields(lines[0])).To(gomega.Equal([]string{"FIELD", "VALUE", "SOURCE"}))
+		})
+	})
+})
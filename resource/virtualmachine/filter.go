@@ -4,14 +4,11 @@ import (
 	"sync"
 	"time"
 
-	"github.com/karrick/tparse/v2"
-
 	"github.com/goat-project/goat-one/resource"
 
 	"github.com/onego-project/onego/resources"
 
-	"github.com/goat-project/goat-one/constants"
-	"github.com/spf13/viper"
+	"github.com/goat-project/goat-one/window"
 
 	"github.com/onego-project/onego/errors"
 
@@ -24,53 +21,19 @@ type Filter struct {
 	recordsTo   time.Time
 }
 
-// CreateFilter creates Filter.
+// CreateFilter creates Filter with the window from configuration.
 func CreateFilter() *Filter {
-	recordsFrom := viper.GetTime(constants.CfgRecordsFrom)
-	recordsTo := viper.GetTime(constants.CfgRecordsTo)
-
-	periodStr := viper.GetString(constants.CfgRecordsForPeriod)
-	period, err := tparse.AddDuration(time.Time{}, periodStr)
-	if err != nil {
-		log.WithFields(log.Fields{"period": periodStr}).Error("wrong format of period")
-		period = time.Time{}
-	}
+	recordsFrom, recordsTo := window.Create()
 
-	if (!recordsFrom.Equal(time.Time{}) || !recordsTo.Equal(time.Time{})) && !period.Equal(time.Time{}) {
-		log.WithFields(log.Fields{
-			"records-from": recordsFrom, "records-to": recordsTo, "period": periodStr,
-		}).Fatal("cannot filter records from/to and records for a period in the same time")
-	}
-
-	if !period.Equal(time.Time{}) {
-		now := time.Now()
-		recFrom, err := tparse.AddDuration(now, "-"+periodStr)
-		if err != nil {
-			log.WithFields(log.Fields{"period": periodStr}).Error("wrong format of period")
-		}
-
-		log.WithFields(log.Fields{
-			"record-from": recFrom, "record-to": now, "period": periodStr,
-		}).Debug("filter set by a period")
-
-		return &Filter{
-			recordsFrom: recFrom,
-			recordsTo:   now,
-		}
-	}
-
-	if recordsTo.Equal(time.Time{}) {
-		now := time.Now()
-
-		log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": now}).Debug("filter from a given time to now")
-
-		return &Filter{
-			recordsFrom: recordsFrom,
-			recordsTo:   now,
-		}
+	return &Filter{
+		recordsFrom: recordsFrom,
+		recordsTo:   recordsTo,
 	}
+}
 
-	log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": recordsTo}).Debug("filter set by times from and to")
+// CreateWindowFilter creates Filter for a given window regardless of configuration.
+func CreateWindowFilter(recordsFrom, recordsTo time.Time) *Filter {
+	log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": recordsTo}).Debug("filter set by a window")
 
 	return &Filter{
 		recordsFrom: recordsFrom,
@@ -78,6 +41,11 @@ func CreateFilter() *Filter {
 	}
 }
 
+// Window returns times from/to the filter filters records.
+func (f *Filter) Window() (time.Time, time.Time) {
+	return f.recordsFrom, f.recordsTo
+}
+
 // Filtering provides filtering given resources according to configuration or command line flags
 // and writing to filtered channel.
 func (f *Filter) Filtering(res resource.Resource, filtered chan resource.Resource, wg *sync.WaitGroup) {
@@ -88,6 +56,12 @@ func (f *Filter) Filtering(res resource.Resource, filtered chan resource.Resourc
 		return
 	}
 
+	if avm, ok := res.(*AccountedVirtualMachine); ok {
+		// OpenNebula accounting lists only virtual machines running in the filter window
+		filtered <- avm
+		return
+	}
+
 	vm := res.(*resources.VirtualMachine)
 
 	id, err := vm.ID()
@@ -116,3 +90,16 @@ func (f *Filter) Filtering(res resource.Resource, filtered chan resource.Resourc
 		filtered <- vm
 	}
 }
+
+// clip clips a period to the filter window. It returns false when the period is out of the window.
+func (f *Filter) clip(start, end time.Time) (time.Time, time.Time, bool) {
+	if start.Before(f.recordsFrom) {
+		start = f.recordsFrom
+	}
+
+	if !f.recordsTo.IsZero() && end.After(f.recordsTo) {
+		end = f.recordsTo
+	}
+
+	return start, end, end.After(start)
+}
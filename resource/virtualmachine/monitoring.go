@@ -0,0 +1,134 @@
+package virtualmachine
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/goat-project/goat-one/constants"
+
+	"github.com/golang/protobuf/ptypes/duration"
+
+	"github.com/onego-project/onego/resources"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// cpuSample represents CPU usage of a virtual machine at a time in percents of one CPU.
+type cpuSample struct {
+	time time.Time
+	cpu  float64
+}
+
+// getCPUSamples lists monitoring records of a virtual machine and returns its CPU samples. Monitoring records
+// are listed even when accounting is canceled since the virtual machine record is written.
+func getCPUSamples(p *Preparer, id int) []cpuSample {
+	records, err := p.reader.ListVirtualMachineMonitoring(context.Background(), id)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "id": id}).Error("error list virtual machine monitoring")
+		return nil
+	}
+
+	return createCPUSamples(records)
+}
+
+// createCPUSamples returns CPU samples of monitoring records sorted by time. A monitoring record is a virtual
+// machine with LAST_POLL and MONITORING/CPU (OpenNebula 5) or MONITORING with TIMESTAMP and CPU (OpenNebula 6).
+// Records without time or CPU usage are skipped.
+func createCPUSamples(records []*etree.Element) []cpuSample {
+	samples := make([]cpuSample, 0, len(records))
+	for _, record := range records {
+		t := record.SelectElement("TIMESTAMP")
+		if t == nil {
+			t = record.SelectElement("LAST_POLL")
+		}
+
+		cpu := record.SelectElement("CPU")
+		if cpu == nil {
+			cpu = record.FindElement("MONITORING/CPU")
+		}
+
+		if t == nil || cpu == nil {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(t.Text(), 10, 64)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get time of monitoring record")
+			continue
+		}
+
+		usage, err := strconv.ParseFloat(cpu.Text(), 64)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error get CPU of monitoring record")
+			continue
+		}
+
+		samples = append(samples, cpuSample{time: time.Unix(seconds, 0), cpu: usage})
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].time.Before(samples[j].time)
+	})
+
+	return samples
+}
+
+// meanCPU returns time-weighted mean CPU usage of samples between start and end. CPU usage between two samples
+// is interpolated linearly. It returns false when there is no sample between start and end.
+func meanCPU(samples []cpuSample, start, end time.Time) (float64, bool) {
+	var window []cpuSample
+	for _, sample := range samples {
+		if !sample.time.Before(start) && !sample.time.After(end) {
+			window = append(window, sample)
+		}
+	}
+
+	if len(window) == 0 {
+		return 0, false
+	}
+
+	var area, total float64
+	for i := 1; i < len(window); i++ {
+		dt := window[i].time.Sub(window[i-1].time).Seconds()
+		area += dt * (window[i-1].cpu + window[i].cpu) / 2
+		total += dt
+	}
+
+	if total == 0 {
+		return window[0].cpu, true
+	}
+
+	return area / total, true
+}
+
+// getCPUDuration returns CPU duration of usage by the strategy set by configuration. Monitored CPU duration
+// is the wall duration multiplied by mean CPU usage in the usage period, it is nil when there is no sample
+// in the period.
+func getCPUDuration(p *Preparer, vm *resources.VirtualMachine, u *usage, samples []cpuSample) *duration.Duration {
+	if u.wallDuration == nil {
+		return nil
+	}
+
+	switch p.cpuDuration {
+	case constants.CPUDurationWallVCPU:
+		return &duration.Duration{Seconds: u.wallDuration.GetSeconds() * int64(getCPUCount(vm))}
+	case constants.CPUDurationMonitored:
+		end := time.Now()
+		if u.endTime != nil {
+			end = time.Unix(u.endTime.GetSeconds(), 0)
+		}
+
+		cpu, ok := meanCPU(samples, time.Unix(u.startTime.GetSeconds(), 0), end)
+		if !ok {
+			return nil
+		}
+
+		return &duration.Duration{Seconds: int64(float64(u.wallDuration.GetSeconds()) * cpu / 100)}
+	default:
+		return u.wallDuration
+	}
+}
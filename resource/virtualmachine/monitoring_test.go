@@ -0,0 +1,112 @@
+package virtualmachine
+
+import (
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/constants"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Virtual machine monitoring tests", func() {
+	var (
+		samples []cpuSample
+		vm      *resources.VirtualMachine
+		u       *usage
+	)
+
+	ginkgo.BeforeEach(func() {
+		doc := etree.NewDocument()
+		gomega.Expect(doc.ReadFromFile("test/xml/monitoring.xml")).NotTo(gomega.HaveOccurred())
+
+		samples = createCPUSamples(doc.Root().ChildElements())
+
+		vmDoc := etree.NewDocument()
+		gomega.Expect(vmDoc.ReadFromString("<VM><ID>57502</ID><TEMPLATE><VCPU>4</VCPU></TEMPLATE></VM>")).NotTo(
+			gomega.HaveOccurred())
+
+		vm = resources.CreateVirtualMachineFromXML(vmDoc.Root())
+		u = &usage{
+			startTime:    &timestamp.Timestamp{Seconds: 1541000000},
+			endTime:      &timestamp.Timestamp{Seconds: 1541001200},
+			wallDuration: &duration.Duration{Seconds: 1000},
+		}
+	})
+
+	ginkgo.Describe("create CPU samples", func() {
+		ginkgo.It("should return samples with CPU usage sorted by time", func() {
+			gomega.Expect(samples).To(gomega.Equal([]cpuSample{
+				{time: time.Unix(1541000000, 0), cpu: 50},
+				{time: time.Unix(1541000600, 0), cpu: 100},
+				{time: time.Unix(1541001200, 0), cpu: 150},
+				{time: time.Unix(1541002400, 0), cpu: 200},
+			}))
+		})
+	})
+
+	ginkgo.Describe("mean CPU", func() {
+		ginkgo.Context("when samples are in the period", func() {
+			ginkgo.It("should return time-weighted mean of samples in the period", func() {
+				cpu, ok := meanCPU(samples, time.Unix(1541000000, 0), time.Unix(1541001200, 0))
+				gomega.Expect(ok).To(gomega.BeTrue())
+				gomega.Expect(cpu).To(gomega.Equal(float64(100)))
+
+				cpu, ok = meanCPU(samples, time.Unix(1540000000, 0), time.Unix(1542000000, 0))
+				gomega.Expect(ok).To(gomega.BeTrue())
+				gomega.Expect(cpu).To(gomega.Equal(137.5))
+			})
+		})
+
+		ginkgo.Context("when only one sample is in the period", func() {
+			ginkgo.It("should return the sample", func() {
+				cpu, ok := meanCPU(samples, time.Unix(1541002000, 0), time.Unix(1541003000, 0))
+				gomega.Expect(ok).To(gomega.BeTrue())
+				gomega.Expect(cpu).To(gomega.Equal(float64(200)))
+			})
+		})
+
+		ginkgo.Context("when no sample is in the period", func() {
+			ginkgo.It("should return false", func() {
+				_, ok := meanCPU(samples, time.Unix(1530000000, 0), time.Unix(1540000000, 0))
+				gomega.Expect(ok).To(gomega.BeFalse())
+			})
+		})
+	})
+
+	ginkgo.Describe("getCPUDuration", func() {
+		ginkgo.Context("when strategy is wall", func() {
+			ginkgo.It("should return wall duration", func() {
+				p := &Preparer{cpuDuration: constants.CPUDurationWall}
+
+				gomega.Expect(getCPUDuration(p, vm, u, samples).GetSeconds()).To(gomega.Equal(int64(1000)))
+			})
+		})
+
+		ginkgo.Context("when strategy is wall*vcpu", func() {
+			ginkgo.It("should return wall duration multiplied by count of virtual CPUs", func() {
+				p := &Preparer{cpuDuration: constants.CPUDurationWallVCPU}
+
+				gomega.Expect(getCPUDuration(p, vm, u, samples).GetSeconds()).To(gomega.Equal(int64(4000)))
+			})
+		})
+
+		ginkgo.Context("when strategy is monitored", func() {
+			ginkgo.It("should return wall duration multiplied by mean CPU usage", func() {
+				p := &Preparer{cpuDuration: constants.CPUDurationMonitored}
+				u.wallDuration = &duration.Duration{Seconds: 1200}
+
+				gomega.Expect(getCPUDuration(p, vm, u, samples).GetSeconds()).To(gomega.Equal(int64(1200)))
+			})
+
+			ginkgo.It("should return nil when there is no sample in the period", func() {
+				p := &Preparer{cpuDuration: constants.CPUDurationMonitored}
+
+				gomega.Expect(getCPUDuration(p, vm, u, nil)).To(gomega.BeNil())
+			})
+		})
+	})
+})
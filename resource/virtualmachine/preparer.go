@@ -1,25 +1,25 @@
 package virtualmachine
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
 
-	"google.golang.org/grpc"
-
 	"github.com/goat-project/goat-one/initialize"
 
 	"github.com/goat-project/goat-one/util"
 
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 
 	"github.com/goat-project/goat-one/writer"
 
-	"golang.org/x/time/rate"
-
 	"github.com/goat-project/goat-one/reader"
 
 	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
 
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -32,62 +32,88 @@ import (
 	pb "github.com/goat-project/goat-proto-go"
 
 	log "github.com/sirupsen/logrus"
-
-	"github.com/google/uuid"
 )
 
 // Preparer to prepare virtual machine data to specific structure for writing to Goat server.
 type Preparer struct {
 	reader                                 reader.Reader
-	Writer                                 writer.Writer
+	filter                                 Filter
+	reporter                               *report.Reporter
+	clipToWindow                           bool
+	recordPerHistory                       bool
+	cpuDuration                            string
+	Writer                                 writer.Interface
 	userTemplateIdentity                   map[int]string
 	imageTemplateCloudkeeperApplianceMpuri map[int]string
 	hostTemplateBenchmarkType              map[int]string
 	hostTemplateBenchmarkValue             map[int]string
 }
 
-// CreatePreparer creates Preparer for virtual machine records.
-func CreatePreparer(reader *reader.Reader, limiter *rate.Limiter, conn *grpc.ClientConn) *Preparer {
+// usage represents a period of a virtual machine the record is prepared for and the benchmark
+// of the host it was running on.
+type usage struct {
+	recordID      string
+	startTime     *timestamp.Timestamp
+	endTime       *timestamp.Timestamp
+	wallDuration  *duration.Duration
+	benchmarkType *wrappers.StringValue
+	benchmark     *wrappers.FloatValue
+}
+
+// CreatePreparer creates Preparer for virtual machine records. Usage of virtual machines is clipped
+// to the filter window and split by history records and CPU duration is computed by the strategy
+// set by configuration. Records which cannot be prepared or written are reported to the reporter.
+func CreatePreparer(reader *reader.Reader, w writer.Interface, f *Filter, rep *report.Reporter) *Preparer {
 	if reader == nil {
 		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepReaderNil)
 		return nil
 	}
 
-	if limiter == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepLimiterNil)
+	if f == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepFilterNil)
 		return nil
 	}
 
-	if conn == nil {
-		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepConnNil)
+	if w == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepWriterNil)
+		return nil
+	}
+
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreatePrepReporterNil)
 		return nil
 	}
 
 	return &Preparer{
-		reader: *reader,
-		Writer: *writer.CreateWriter(CreateWriter(limiter), conn),
+		reader:           *reader,
+		filter:           *f,
+		reporter:         rep,
+		clipToWindow:     viper.GetBool(constants.CfgClipToWindow),
+		recordPerHistory: viper.GetBool(constants.CfgRecordPerHistory),
+		cpuDuration:      viper.GetString(constants.CfgCPUDuration),
+		Writer:           w,
 	}
 }
 
 // InitializeMaps reads additional data for virtual machine record.
-func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
+func (p *Preparer) InitializeMaps(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	wg.Add(3)
 
 	go func() {
 		defer wg.Done()
-		p.userTemplateIdentity = initialize.UserTemplateIdentity(p.reader)
+		p.userTemplateIdentity = initialize.UserTemplateIdentity(ctx, p.reader)
 	}()
 
 	go func() {
 		defer wg.Done()
-		p.imageTemplateCloudkeeperApplianceMpuri = initialize.ImageTemplateCloudkeeperApplianceMpuri(p.reader)
+		p.imageTemplateCloudkeeperApplianceMpuri = initialize.ImageTemplateCloudkeeperApplianceMpuri(ctx, p.reader)
 	}()
 
 	go func() {
 		defer wg.Done()
-		p.hostTemplateBenchmarkType, p.hostTemplateBenchmarkType = initialize.HostTemplateBenchmark(p.reader)
+		p.hostTemplateBenchmarkType, p.hostTemplateBenchmarkValue = initialize.HostTemplateBenchmark(ctx, p.reader)
 	}()
 }
 
@@ -95,70 +121,110 @@ func (p *Preparer) InitializeMaps(wg *sync.WaitGroup) {
 func (p *Preparer) Preparation(acc resource.Resource, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	var history []*History
+	accounted := false
+	if avm, ok := acc.(*AccountedVirtualMachine); ok {
+		acc, history, accounted = avm.VirtualMachine, avm.History, true
+	}
+
 	vm := acc.(*resources.VirtualMachine)
 	if vm == nil {
-		log.WithFields(log.Fields{"error": errors.ErrNoVirtualMachine}).Error(constants.ErrPrepEmptyVM)
+		p.reporter.Skip(constants.ResourceVM, constants.ErrPrepEmptyVM, log.Fields{}, errors.ErrNoVirtualMachine)
 		return
 	}
 
 	id, err := vm.ID()
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Error(constants.ErrPrepNoVM)
+		p.reporter.Skip(constants.ResourceVM, constants.ErrPrepNoVM, log.Fields{}, err)
 		return
 	}
 
 	machineName, err := getMachineName(vm)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepMachineName)
+		p.reporter.Skip(constants.ResourceVM, constants.ErrPrepMachineName, log.Fields{"id": id}, err)
 		return
 	}
 
 	globalUserName, err := getGlobalUserName(p, vm)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepGlobalUserName)
+		p.reporter.Skip(constants.ResourceVM, constants.ErrPrepGlobalUserName, log.Fields{"id": id}, err)
 		return
 	}
 
-	sTime, err := getStartTime(vm)
+	var usages []*usage
+	switch {
+	case p.recordPerHistory:
+		if !accounted {
+			history = getHistory(p, vm)
+		}
+
+		var window []interface{}
+		if accounted || p.clipToWindow {
+			window = getWindowBounds(p)
+		}
+
+		usages = getHistoryUsages(p, id, history, window)
+	case accounted:
+		usages, err = single(getWindowUsage(p, id, vm, history))
+	case p.clipToWindow:
+		usages, err = single(getWindowUsage(p, id, vm, getHistory(p, vm)))
+	default:
+		usages, err = single(getUsage(p, id, vm))
+	}
+
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepSTime)
+		p.reporter.Skip(constants.ResourceVM, constants.ErrPrepSTime, log.Fields{"id": id}, err)
 		return
 	}
 
-	eTime := getEndTime(vm)
-	wallDuration := getWallDuration(vm)
-
-	vmRecord := pb.VmRecord{
-		VmUuid:              uuid.New().String(),
-		SiteName:            getSiteName(),
-		CloudComputeService: getCloudComputeService(),
-		MachineName:         machineName,
-		LocalUserId:         getLocalUserID(vm),
-		LocalGroupId:        getLocalGroupID(vm),
-		GlobalUserName:      globalUserName,
-		Fqan:                getFqan(vm),
-		Status:              getStatus(vm),
-		StartTime:           sTime,
-		EndTime:             eTime,
-		SuspendDuration:     getSuspendDuration(sTime, eTime, wallDuration),
-		WallDuration:        wallDuration,
-		CpuDuration:         wallDuration,
-		CpuCount:            getCPUCount(vm),
-		NetworkType:         getNetworkType(),
-		NetworkInbound:      getNetworkInbound(vm),
-		NetworkOutbound:     getNetworkOutbound(vm),
-		PublicIpCount:       getPublicIPCount(vm),
-		Memory:              getMemory(vm),
-		Disk:                getDiskSizes(vm),
-		BenchmarkType:       getBenchmarkType(p, vm),
-		Benchmark:           getBenchmark(p, vm),
-		StorageRecordId:     nil,
-		ImageId:             getImageID(p, vm),
-		CloudType:           getCloudType(),
-	}
-
-	if err := p.Writer.Write(&vmRecord); err != nil {
-		log.WithFields(log.Fields{"error": err, "id": id}).Error(constants.ErrPrepWrite)
+	var samples []cpuSample
+	if p.cpuDuration == constants.CPUDurationMonitored {
+		samples = getCPUSamples(p, id)
+	}
+
+	for _, u := range usages {
+		vmRecord := pb.VmRecord{
+			VmUuid:              u.recordID,
+			SiteName:            getSiteName(),
+			CloudComputeService: getCloudComputeService(),
+			MachineName:         machineName,
+			LocalUserId:         getLocalUserID(vm),
+			LocalGroupId:        getLocalGroupID(vm),
+			GlobalUserName:      globalUserName,
+			Fqan:                getFqan(vm),
+			Status:              getStatus(vm),
+			StartTime:           u.startTime,
+			EndTime:             u.endTime,
+			SuspendDuration:     getSuspendDuration(u.startTime, u.endTime, u.wallDuration),
+			WallDuration:        u.wallDuration,
+			CpuDuration:         getCPUDuration(p, vm, u, samples),
+			CpuCount:            getCPUCount(vm),
+			NetworkType:         getNetworkType(),
+			NetworkInbound:      getNetworkInbound(vm),
+			NetworkOutbound:     getNetworkOutbound(vm),
+			PublicIpCount:       getPublicIPCount(vm),
+			Memory:              getMemory(vm),
+			Disk:                getDiskSizes(vm),
+			BenchmarkType:       u.benchmarkType,
+			Benchmark:           u.benchmark,
+			StorageRecordId:     nil,
+			ImageId:             getImageID(p, vm),
+			CloudType:           getCloudType(),
+		}
+
+		metrics.Count(constants.ResourceVM, metrics.StagePrepared)
+
+		if err := p.Writer.Write(&vmRecord); err != nil {
+			if _, rejected := err.(*writer.RejectedError); rejected {
+				p.reporter.Reject(constants.ResourceVM)
+				continue
+			}
+
+			p.reporter.Skip(constants.ResourceVM, constants.ErrPrepWrite, log.Fields{"id": id}, err)
+			continue
+		}
+
+		metrics.Count(constants.ResourceVM, metrics.StageWritten)
 	}
 }
 
@@ -167,8 +233,7 @@ func (p *Preparer) SendIdentifier() error {
 	return p.Writer.SendIdentifier()
 }
 
-// Finish gets to know to the Goat server that a writing is finished and a response is expected.
-// Then, it closes the gRPC connection.
+// Finish gets to know to the writer that a writing is finished.
 func (p *Preparer) Finish() {
 	p.Writer.Finish()
 }
@@ -242,6 +307,190 @@ func getStatus(vm *resources.VirtualMachine) *wrappers.StringValue {
 	return nil
 }
 
+// getUsage returns usage for the whole lifetime of a virtual machine.
+func getUsage(p *Preparer, id int, vm *resources.VirtualMachine) (*usage, error) {
+	sTime, err := getStartTime(vm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage{
+		recordID:      getRecordID(id),
+		startTime:     sTime,
+		endTime:       getEndTime(vm),
+		wallDuration:  getWallDuration(vm),
+		benchmarkType: getBenchmarkType(p, vm),
+		benchmark:     getBenchmark(p, vm),
+	}, nil
+}
+
+// getWindowUsage returns usage of a virtual machine bounded by the filter window. Wall duration is a sum
+// of history records clipped to the window, the benchmark is taken from the host of the first history record.
+// Record ID is derived from bounds of the window too, so usages of different windows are not de-duplicated.
+func getWindowUsage(p *Preparer, id int, vm *resources.VirtualMachine, history []*History) (*usage, error) {
+	sTime, err := vm.STime()
+	if err != nil {
+		return nil, err
+	}
+
+	if sTime == nil {
+		return nil, fmt.Errorf("no start time")
+	}
+
+	eTime, err := vm.ETime()
+	if err != nil || eTime == nil || eTime.Unix() == 0 {
+		now := time.Now()
+		eTime = &now
+	}
+
+	start, end, ok := p.filter.clip(*sTime, *eTime)
+	if !ok {
+		end = start
+	}
+
+	var wall int64
+	for _, h := range history {
+		wall += h.End.Unix() - h.Start.Unix()
+	}
+
+	u := &usage{
+		recordID:     getRecordID(id, getWindowBounds(p)...),
+		startTime:    &timestamp.Timestamp{Seconds: start.Unix()},
+		endTime:      &timestamp.Timestamp{Seconds: end.Unix()},
+		wallDuration: &duration.Duration{Seconds: wall},
+	}
+
+	if len(history) > 0 {
+		u.benchmarkType = getHostBenchmarkType(p, history[0].HostID)
+		u.benchmark = getHostBenchmark(p, history[0].HostID)
+	}
+
+	return u, nil
+}
+
+// getHistoryUsages returns usage for every history record of a virtual machine. Each usage has the benchmark
+// of the host and a record ID derived from virtual machine ID, sequence number of the history record and bounds
+// of the window when history records are bounded by it.
+func getHistoryUsages(p *Preparer, id int, history []*History, window []interface{}) []*usage {
+	usages := make([]*usage, 0, len(history))
+	for _, h := range history {
+		usages = append(usages, &usage{
+			recordID:      getHistoryRecordID(id, h.Seq, window...),
+			startTime:     &timestamp.Timestamp{Seconds: h.Start.Unix()},
+			endTime:       &timestamp.Timestamp{Seconds: h.End.Unix()},
+			wallDuration:  &duration.Duration{Seconds: h.End.Unix() - h.Start.Unix()},
+			benchmarkType: getHostBenchmarkType(p, h.HostID),
+			benchmark:     getHostBenchmark(p, h.HostID),
+		})
+	}
+
+	return usages
+}
+
+// getRecordID returns record ID of a virtual machine derived from OpenNebula endpoint, virtual machine ID
+// and bounds of the window if the usage is bounded by it.
+func getRecordID(id int, window ...interface{}) string {
+	parts := append([]interface{}{constants.ResourceVM, id}, window...)
+
+	return util.RecordID(viper.GetString(constants.CfgOpennebulaEndpoint), parts...)
+}
+
+// getHistoryRecordID returns record ID of a history record derived from OpenNebula endpoint, virtual machine ID,
+// sequence number of the history record and bounds of the window if the history record is bounded by it.
+func getHistoryRecordID(id, seq int, window ...interface{}) string {
+	parts := append([]interface{}{constants.ResourceVM, id, seq}, window...)
+
+	return util.RecordID(viper.GetString(constants.CfgOpennebulaEndpoint), parts...)
+}
+
+// getWindowBounds returns bounds of the filter window in seconds as parts of record ID. Unset bound is 0.
+func getWindowBounds(p *Preparer) []interface{} {
+	bounds := make([]interface{}, 0, 2)
+	for _, t := range []time.Time{p.filter.recordsFrom, p.filter.recordsTo} {
+		if t.IsZero() {
+			bounds = append(bounds, int64(0))
+			continue
+		}
+
+		bounds = append(bounds, t.Unix())
+	}
+
+	return bounds
+}
+
+func single(u *usage, err error) ([]*usage, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return []*usage{u}, nil
+}
+
+// getHistory returns history records of a virtual machine, clipped to the filter window when it is set
+// by configuration.
+func getHistory(p *Preparer, vm *resources.VirtualMachine) []*History {
+	if vm.XMLData == nil {
+		return nil
+	}
+
+	segments, err := historySegments(vm, time.Now())
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("error get history records")
+		return nil
+	}
+
+	if !p.clipToWindow {
+		return segments
+	}
+
+	var history []*History
+	for _, h := range segments {
+		start, end, ok := p.filter.clip(h.Start, h.End)
+		if !ok {
+			continue
+		}
+
+		history = append(history, &History{Seq: h.Seq, HostID: h.HostID, Start: start, End: end})
+	}
+
+	return history
+}
+
+// historySegments returns history records of a virtual machine as segments of its run on hosts. The last history
+// records with RETIME zero did not end yet, they end at a given time as well as the last history record without
+// RETIME which is the running segment. Other history records without RETIME are skipped.
+func historySegments(vm *resources.VirtualMachine, now time.Time) ([]*History, error) {
+	historyRecords, err := vm.HistoryRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []*History
+	for i, record := range historyRecords {
+		if record == nil || record.RSTime == nil || record.SEQ == nil || record.HID == nil {
+			continue
+		}
+
+		end := now
+		if record.RETime == nil {
+			if i != len(historyRecords)-1 {
+				continue
+			}
+		} else if record.RETime.Unix() != 0 {
+			end = *record.RETime
+		}
+
+		segments = append(segments, &History{
+			Seq:    *record.SEQ,
+			HostID: *record.HID,
+			Start:  *record.RSTime,
+			End:    end,
+		})
+	}
+
+	return segments, nil
+}
+
 func getStartTime(vm *resources.VirtualMachine) (*timestamp.Timestamp, error) {
 	ts, err := util.CheckTime(vm.STime())
 	if err != nil {
@@ -274,34 +523,15 @@ func getWallDuration(vm *resources.VirtualMachine) *duration.Duration {
 		return nil
 	}
 
-	historyRecords, err := vm.HistoryRecords()
+	segments, err := historySegments(vm, time.Now())
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("error get history records")
 		return nil
 	}
 
-	currentTime := time.Now().Unix()
-
 	var sum int64
-	for _, record := range historyRecords {
-		if record != nil {
-			rsTime := record.RSTime
-			if rsTime == nil {
-				continue
-			}
-
-			reTime := record.RETime
-			if reTime == nil {
-				continue
-			}
-
-			reTimeUnix := reTime.Unix()
-			if reTimeUnix == 0 {
-				reTimeUnix = currentTime
-			}
-
-			sum += reTimeUnix - rsTime.Unix()
-		}
+	for _, h := range segments {
+		sum += h.End.Unix() - h.Start.Unix()
 	}
 
 	return &duration.Duration{Seconds: sum}
@@ -377,11 +607,21 @@ func getBenchmarkType(p *Preparer, vm *resources.VirtualMachine) *wrappers.Strin
 	}
 
 	historyRecords, err := vm.HistoryRecords()
-	if err == nil && len(historyRecords) > 0 {
-		tbt := p.hostTemplateBenchmarkType[*historyRecords[0].HID]
-		if tbt != "" {
-			return &wrappers.StringValue{Value: tbt}
-		}
+	if err == nil && len(historyRecords) > 0 && historyRecords[0].HID != nil {
+		return getHostBenchmarkType(p, *historyRecords[0].HID)
+	}
+
+	return nil
+}
+
+func getHostBenchmarkType(p *Preparer, hostID int) *wrappers.StringValue {
+	if p == nil {
+		return nil
+	}
+
+	tbt := p.hostTemplateBenchmarkType[hostID]
+	if tbt != "" {
+		return &wrappers.StringValue{Value: tbt}
 	}
 
 	return nil
@@ -393,13 +633,23 @@ func getBenchmark(p *Preparer, vm *resources.VirtualMachine) *wrappers.FloatValu
 	}
 
 	historyRecords, err := vm.HistoryRecords()
-	if err == nil && len(historyRecords) > 0 {
-		tbv := p.hostTemplateBenchmarkValue[*historyRecords[0].HID]
-		if tbv != "" {
-			f, err := strconv.ParseFloat(tbv, 32)
-			if err == nil {
-				return &wrappers.FloatValue{Value: float32(f)}
-			}
+	if err == nil && len(historyRecords) > 0 && historyRecords[0].HID != nil {
+		return getHostBenchmark(p, *historyRecords[0].HID)
+	}
+
+	return nil
+}
+
+func getHostBenchmark(p *Preparer, hostID int) *wrappers.FloatValue {
+	if p == nil {
+		return nil
+	}
+
+	tbv := p.hostTemplateBenchmarkValue[hostID]
+	if tbv != "" {
+		f, err := strconv.ParseFloat(tbv, 32)
+		if err == nil {
+			return &wrappers.FloatValue{Value: float32(f)}
 		}
 	}
 
@@ -1,6 +1,8 @@
 package virtualmachine
 
 import (
+	"time"
+
 	"github.com/beevik/etree"
 	"github.com/goat-project/goat-one/constants"
 	"github.com/golang/protobuf/ptypes/duration"
@@ -309,9 +311,168 @@ var _ = ginkgo.Describe("Preparer function test", func() {
 
 		ginkgo.Context("when configuration is not set correctly", func() {
 			ginkgo.It("should return a string value", func() {
+				// the finished history record and the running one until now
+				running := time.Now().Unix() - 1526919245
+
 				gomega.Expect(
 					getWallDuration(resources.CreateVirtualMachineFromXML(doc.Root())).GetSeconds()).To(
-					gomega.Equal(int64(7707605)))
+					gomega.BeNumerically("~", 7707605+running, 1))
+			})
+		})
+	})
+
+	ginkgo.Describe("getHistory", func() {
+		ginkgo.Context("when virtual machine has no data", func() {
+			ginkgo.It("should return no history records", func() {
+				gomega.Expect(getHistory(&Preparer{}, &resources.VirtualMachine{})).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when history records overlap the window", func() {
+			ginkgo.It("should return history records clipped to the window", func() {
+				preparer := &Preparer{clipToWindow: true, filter: Filter{
+					recordsFrom: time.Unix(1526000000, 0),
+					recordsTo:   time.Unix(1527000000, 0),
+				}}
+
+				gomega.Expect(getHistory(preparer, resources.CreateVirtualMachineFromXML(doc.Root()))).To(
+					gomega.Equal([]*History{
+						{Seq: 0, HostID: 932, Start: time.Unix(1526000000, 0), End: time.Unix(1526917399, 0)},
+						{Seq: 1, HostID: 932, Start: time.Unix(1526919245, 0), End: time.Unix(1527000000, 0)},
+					}))
+			})
+		})
+
+		ginkgo.Context("when history records are out of the window", func() {
+			ginkgo.It("should return no history records", func() {
+				preparer := &Preparer{clipToWindow: true, filter: Filter{
+					recordsFrom: time.Unix(1510000000, 0),
+					recordsTo:   time.Unix(1519000000, 0),
+				}}
+
+				gomega.Expect(getHistory(preparer, resources.CreateVirtualMachineFromXML(doc.Root()))).To(
+					gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when history records are not clipped", func() {
+			ginkgo.It("should return whole history records", func() {
+				history := getHistory(&Preparer{}, resources.CreateVirtualMachineFromXML(doc.Root()))
+
+				gomega.Expect(history).To(gomega.HaveLen(2))
+				gomega.Expect(history[0]).To(gomega.Equal(&History{Seq: 0, HostID: 932,
+					Start: time.Unix(1519209794, 0), End: time.Unix(1526917399, 0)}))
+				gomega.Expect(history[1].Start).To(gomega.Equal(time.Unix(1526919245, 0)))
+			})
+		})
+	})
+
+	ginkgo.Describe("historySegments", func() {
+		ginkgo.Context("when history records have zero end time", func() {
+			ginkgo.It("should end all of them at the given time", func() {
+				vmDoc := etree.NewDocument()
+				gomega.Expect(vmDoc.ReadFromString("<VM><ID>1</ID><HISTORY_RECORDS>" +
+					"<HISTORY><SEQ>0</SEQ><HID>1</HID><RSTIME>100</RSTIME><RETIME>0</RETIME></HISTORY>" +
+					"<HISTORY><SEQ>1</SEQ><HID>2</HID><RSTIME>200</RSTIME><RETIME>300</RETIME></HISTORY>" +
+					"<HISTORY><SEQ>2</SEQ><HID>3</HID><RSTIME>400</RSTIME><RETIME>0</RETIME></HISTORY>" +
+					"</HISTORY_RECORDS></VM>")).To(gomega.Succeed())
+				vm := resources.CreateVirtualMachineFromXML(vmDoc.Root())
+
+				segments, err := historySegments(vm, time.Unix(500, 0))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(segments).To(gomega.Equal([]*History{
+					{Seq: 0, HostID: 1, Start: time.Unix(100, 0), End: time.Unix(500, 0)},
+					{Seq: 1, HostID: 2, Start: time.Unix(200, 0), End: time.Unix(300, 0)},
+					{Seq: 2, HostID: 3, Start: time.Unix(400, 0), End: time.Unix(500, 0)},
+				}))
+			})
+		})
+
+		ginkgo.Context("when history records have no end time", func() {
+			ginkgo.It("should end the last one at the given time and skip the others", func() {
+				vmDoc := etree.NewDocument()
+				gomega.Expect(vmDoc.ReadFromString("<VM><ID>1</ID><HISTORY_RECORDS>" +
+					"<HISTORY><SEQ>0</SEQ><HID>1</HID><RSTIME>100</RSTIME></HISTORY>" +
+					"<HISTORY><SEQ>1</SEQ><HID>2</HID><RSTIME>200</RSTIME><RETIME>300</RETIME></HISTORY>" +
+					"<HISTORY><SEQ>2</SEQ><HID>3</HID><RSTIME>400</RSTIME></HISTORY>" +
+					"</HISTORY_RECORDS></VM>")).To(gomega.Succeed())
+				vm := resources.CreateVirtualMachineFromXML(vmDoc.Root())
+
+				segments, err := historySegments(vm, time.Unix(500, 0))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(segments).To(gomega.Equal([]*History{
+					{Seq: 1, HostID: 2, Start: time.Unix(200, 0), End: time.Unix(300, 0)},
+					{Seq: 2, HostID: 3, Start: time.Unix(400, 0), End: time.Unix(500, 0)},
+				}))
+			})
+		})
+	})
+
+	ginkgo.Describe("getHistoryUsages", func() {
+		ginkgo.Context("when virtual machine migrated between hosts", func() {
+			ginkgo.It("should return usage with benchmark of every host", func() {
+				preparer := &Preparer{
+					hostTemplateBenchmarkType:  map[int]string{932: "HEP-SPEC06", 933: "HEP-SPEC06"},
+					hostTemplateBenchmarkValue: map[int]string{932: "100", 933: "50"},
+				}
+
+				usages := getHistoryUsages(preparer, 57502, []*History{
+					{Seq: 0, HostID: 932, Start: time.Unix(1540950000, 0), End: time.Unix(1541000000, 0)},
+					{Seq: 1, HostID: 933, Start: time.Unix(1541000100, 0), End: time.Unix(1541050000, 0)},
+				}, nil)
+
+				gomega.Expect(usages).To(gomega.HaveLen(2))
+				gomega.Expect(usages[0].wallDuration.GetSeconds()).To(gomega.Equal(int64(50000)))
+				gomega.Expect(usages[0].benchmark.GetValue()).To(gomega.Equal(float32(100)))
+				gomega.Expect(usages[1].startTime.GetSeconds()).To(gomega.Equal(int64(1541000100)))
+				gomega.Expect(usages[1].endTime.GetSeconds()).To(gomega.Equal(int64(1541050000)))
+				gomega.Expect(usages[1].wallDuration.GetSeconds()).To(gomega.Equal(int64(49900)))
+				gomega.Expect(usages[1].benchmark.GetValue()).To(gomega.Equal(float32(50)))
+				gomega.Expect(usages[0].recordID).NotTo(gomega.Equal(usages[1].recordID))
+			})
+		})
+	})
+
+	ginkgo.Describe("getRecordID", func() {
+		ginkgo.AfterEach(func() {
+			viper.Set(constants.CfgOpennebulaEndpoint, "")
+		})
+
+		ginkgo.It("should return the same ID for the same endpoint and virtual machine", func() {
+			viper.Set(constants.CfgOpennebulaEndpoint, "https://one.example.com:2633/RPC2")
+			id := getRecordID(57502)
+
+			gomega.Expect(getRecordID(57502)).To(gomega.Equal(id))
+			gomega.Expect(getRecordID(57503)).NotTo(gomega.Equal(id))
+
+			viper.Set(constants.CfgOpennebulaEndpoint, "https://other.example.com:2633/RPC2")
+			gomega.Expect(getRecordID(57502)).NotTo(gomega.Equal(id))
+		})
+	})
+
+	ginkgo.Describe("getHistoryRecordID", func() {
+		ginkgo.It("should return the same ID for the same history record", func() {
+			gomega.Expect(getHistoryRecordID(57502, 1)).To(gomega.Equal(getHistoryRecordID(57502, 1)))
+			gomega.Expect(getHistoryRecordID(57502, 1)).NotTo(gomega.Equal(getHistoryRecordID(57502, 0)))
+			gomega.Expect(getHistoryRecordID(57502, 1)).NotTo(gomega.Equal(getHistoryRecordID(5750, 21)))
+		})
+	})
+
+	ginkgo.Describe("getWindowBounds", func() {
+		ginkgo.Context("when usage of a virtual machine is bounded by two different windows", func() {
+			ginkgo.It("should give different record IDs", func() {
+				day := &Preparer{filter: Filter{recordsFrom: time.Unix(1540944000, 0), recordsTo: time.Unix(1541030400, 0)}}
+				next := &Preparer{filter: Filter{recordsFrom: time.Unix(1541030400, 0), recordsTo: time.Unix(1541116800, 0)}}
+
+				gomega.Expect(getRecordID(57502, getWindowBounds(day)...)).To(
+					gomega.Equal(getRecordID(57502, getWindowBounds(day)...)))
+				gomega.Expect(getRecordID(57502, getWindowBounds(day)...)).NotTo(
+					gomega.Equal(getRecordID(57502, getWindowBounds(next)...)))
+				gomega.Expect(getHistoryRecordID(57502, 1, getWindowBounds(day)...)).NotTo(
+					gomega.Equal(getHistoryRecordID(57502, 1, getWindowBounds(next)...)))
+				gomega.Expect(getRecordID(57502, getWindowBounds(day)...)).NotTo(gomega.Equal(getRecordID(57502)))
 			})
 		})
 	})
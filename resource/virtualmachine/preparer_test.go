@@ -1,6 +1,7 @@
 package virtualmachine_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,8 +14,10 @@ import (
 	"github.com/dnaeon/go-vcr/recorder"
 	"github.com/goat-project/goat-one/constants"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource/virtualmachine"
 	"github.com/goat-project/goat-one/util"
+	"github.com/goat-project/goat-one/writer"
 	"github.com/onego-project/onego"
 	"github.com/onego-project/onego/errors"
 	"github.com/onego-project/onego/resources"
@@ -41,10 +44,11 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 		client     *onego.Client
 		vcrRec     *recorder.Recorder
 
-		read *reader.Reader
-		prep *virtualmachine.Preparer
-		wg   sync.WaitGroup
-		hook *test.Hook
+		read     *reader.Reader
+		prep     *virtualmachine.Preparer
+		reporter *report.Reporter
+		wg       sync.WaitGroup
+		hook     *test.Hook
 
 		doc *etree.Document
 	)
@@ -107,7 +111,11 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 		viper.SetDefault(constants.CfgOpennebulaTimeout, constants.OpenNebulaTimeout)
 		read = reader.CreateReader(client, rate.NewLimiter(rate.Every(time.Second/time.Duration(30)), 30))
 
-		prep = virtualmachine.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), conn)
+		w := writer.CreateWriter(virtualmachine.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+		reporter, err = report.CreateReporter(constants.PolicySkipAndReport)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		prep = virtualmachine.CreatePreparer(read, w, virtualmachine.CreateFilter(), reporter)
 		wg.Add(1)
 	})
 
@@ -128,7 +136,7 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 	})
 
 	ginkgo.Describe("create preparer", func() {
-		ginkgo.Context("when limiter is correct", func() {
+		ginkgo.Context("when writer is correct", func() {
 			ginkgo.BeforeEach(func() {
 				recName = "createOK"
 			})
@@ -137,44 +145,44 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 				gomega.Expect(conn).NotTo(gomega.BeNil())
 				gomega.Expect(read).NotTo(gomega.BeNil())
 
-				p := virtualmachine.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), conn)
+				w := writer.CreateWriter(virtualmachine.CreateWriter(rate.NewLimiter(rate.Every(1), 1)), conn)
+				p := virtualmachine.CreatePreparer(read, w, virtualmachine.CreateFilter(), reporter)
 
 				gomega.Expect(p).NotTo(gomega.BeNil())
 			})
 		})
 
-		ginkgo.Context("when limiter is not correct", func() {
+		ginkgo.Context("when writer is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "limiterNil"
+				recName = "writerNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
-				gomega.Expect(conn).NotTo(gomega.BeNil())
 				gomega.Expect(read).NotTo(gomega.BeNil())
 
-				p := virtualmachine.CreatePreparer(read, nil, conn)
+				p := virtualmachine.CreatePreparer(read, nil, virtualmachine.CreateFilter(), reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepLimiterNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepWriterNil))
 			})
 		})
 
-		ginkgo.Context("when connection is not correct", func() {
+		ginkgo.Context("when filter is not correct", func() {
 			ginkgo.BeforeEach(func() {
-				recName = "connectionNil"
+				recName = "filterNil"
 			})
 
 			ginkgo.It("should not create preparer", func() {
 				gomega.Expect(read).NotTo(gomega.BeNil())
 
-				p := virtualmachine.CreatePreparer(read, rate.NewLimiter(rate.Every(1), 1), nil)
+				p := virtualmachine.CreatePreparer(read, prep.Writer, nil, reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
 				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
-				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepConnNil))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepFilterNil))
 			})
 		})
 
@@ -186,7 +194,7 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 			ginkgo.It("should not create preparer", func() {
 				gomega.Expect(conn).NotTo(gomega.BeNil())
 
-				p := virtualmachine.CreatePreparer(nil, rate.NewLimiter(rate.Every(1), 1), conn)
+				p := virtualmachine.CreatePreparer(nil, prep.Writer, virtualmachine.CreateFilter(), reporter)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
@@ -194,6 +202,21 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepReaderNil))
 			})
 		})
+
+		ginkgo.Context("when reporter is not correct", func() {
+			ginkgo.BeforeEach(func() {
+				recName = "writerNil"
+			})
+
+			ginkgo.It("should not create preparer", func() {
+				p := virtualmachine.CreatePreparer(read, prep.Writer, virtualmachine.CreateFilter(), nil)
+
+				gomega.Expect(p).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreatePrepReporterNil))
+			})
+		})
 	})
 
 	ginkgo.Describe("initialize maps for preparer", func() {
@@ -203,7 +226,7 @@ var _ = ginkgo.Describe("Virtual machine Preparer tests", func() {
 			})
 
 			ginkgo.It("should add map with user template identity", func() {
-				prep.InitializeMaps(&wg)
+				prep.InitializeMaps(context.Background(), &wg)
 
 				// TODO map is not visible from this package,
 				//  testing in the same package causes import cycle
@@ -1,44 +1,65 @@
 package virtualmachine
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/goat-project/goat-one/constants"
 
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/resource"
 
+	"github.com/onego-project/onego/resources"
 	"github.com/remeh/sizedwaitgroup"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// maxFailedPages is number of pages which failed to list in a row after which listing stops.
+const maxFailedPages = 3
+
 // Processor to process virtual machine data.
 type Processor struct {
-	reader reader.Reader
+	reader      reader.Reader
+	reporter    *report.Reporter
+	listPage    func(context.Context, int) ([]*resources.VirtualMachine, error)
+	failedPages int32
 }
 
-// CreateProcessor creates processor with reader.
-func CreateProcessor(r *reader.Reader) *Processor {
+// CreateProcessor creates processor with reader and reporter of skipped virtual machines.
+func CreateProcessor(r *reader.Reader, rep *report.Reporter) *Processor {
 	if r == nil {
 		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReaderNil)
 		return nil
 	}
 
-	return &Processor{
-		reader: *r,
+	if rep == nil {
+		log.WithFields(log.Fields{}).Error(constants.ErrCreateProcReporterNil)
+		return nil
+	}
+
+	p := &Processor{
+		reader:   *r,
+		reporter: rep,
 	}
+	p.listPage = p.reader.ListAllVirtualMachines
+
+	return p
 }
 
-// Process provides listing of the virtual machines with pagination.
-func (p *Processor) Process(read chan resource.Resource, readDone chan bool, swg *sizedwaitgroup.SizedWaitGroup) {
+// Process provides listing of the virtual machines with pagination until the context is canceled
+// or errors exceed the error policy.
+func (p *Processor) Process(ctx context.Context, read chan resource.Resource, readDone chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup) {
 	defer swg.Done()
 	pageOffset := 1
 
 processing:
-	for {
+	for ctx.Err() == nil && !p.reporter.Stopped() {
 		swg.Add()
-		go p.list(read, readDone, swg, pageOffset)
+		go p.list(ctx, read, readDone, swg, pageOffset)
 		select {
 		case <-readDone:
 			break processing
@@ -49,16 +70,30 @@ processing:
 	}
 }
 
-// list calls method to list virtual machines by page offset.
-func (p *Processor) list(read chan resource.Resource, readDone chan bool, swg *sizedwaitgroup.SizedWaitGroup,
-	pageOffset int) {
+// list calls method to list virtual machines by page offset. Listing is done when a page is empty. A page which
+// cannot be listed is reported and the following pages are listed, unless pages failed repeatedly, e.g. since
+// OpenNebula is unavailable, or the context is canceled.
+func (p *Processor) list(ctx context.Context, read chan resource.Resource, readDone chan bool,
+	swg *sizedwaitgroup.SizedWaitGroup, pageOffset int) {
 	defer swg.Done()
 
-	vms, err := p.reader.ListAllVirtualMachines(pageOffset)
+	vms, err := p.listPage(ctx, pageOffset)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err, "page-offset": pageOffset}).Fatal("error list virtual machines")
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.reporter.Skip(constants.ResourceVM, "error list virtual machines", log.Fields{"page-offset": pageOffset}, err)
+
+		if atomic.AddInt32(&p.failedPages, 1) >= maxFailedPages {
+			readDone <- true
+		}
+
+		return
 	}
 
+	atomic.StoreInt32(&p.failedPages, 0)
+
 	if len(vms) == 0 {
 		readDone <- true
 		return
@@ -69,18 +104,25 @@ func (p *Processor) list(read chan resource.Resource, readDone chan bool, swg *s
 	}
 }
 
-// RetrieveInfo calls method to retrieve virtual machine info.
-func (p *Processor) RetrieveInfo(fullInfo chan resource.Resource, wg *sync.WaitGroup, vm resource.Resource) {
+// RetrieveInfo calls method to retrieve virtual machine info. Virtual machines are not reported as skipped
+// when the context is canceled.
+func (p *Processor) RetrieveInfo(ctx context.Context, fullInfo chan resource.Resource, wg *sync.WaitGroup,
+	vm resource.Resource) {
 	defer wg.Done()
 
 	id, err := vm.ID()
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error get virtual machine id")
+		p.reporter.Skip(constants.ResourceVM, "error get virtual machine id", log.Fields{}, err)
+		return
 	}
 
-	v, err := p.reader.RetrieveVirtualMachineInfo(id)
+	v, err := p.reader.RetrieveVirtualMachineInfo(ctx, id)
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Fatal("error retrieve virtual machine info")
+		if ctx.Err() == nil {
+			p.reporter.Skip(constants.ResourceVM, "error retrieve virtual machine info", log.Fields{"id": id}, err)
+		}
+
+		return
 	}
 
 	fullInfo <- v
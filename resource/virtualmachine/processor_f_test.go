@@ -0,0 +1,106 @@
+package virtualmachine
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/report"
+	"github.com/goat-project/goat-one/resource"
+	"github.com/onego-project/onego/resources"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// the following tests test listing of virtual machines by pages
+
+var _ = ginkgo.Describe("Virtual machine Processor list tests", func() {
+	var (
+		rep   *report.Reporter
+		pages map[int][]int
+		fails map[int]bool
+		mu    sync.Mutex
+		calls int
+	)
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		rep, err = report.CreateReporter(constants.PolicySkipAndReport)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		calls = 0
+	})
+
+	listPage := func(_ context.Context, pageOffset int) ([]*resources.VirtualMachine, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls++
+
+		if fails[pageOffset] {
+			return nil, errors.New("page cannot be listed")
+		}
+
+		vms := make([]*resources.VirtualMachine, 0, len(pages[pageOffset]))
+		for _, id := range pages[pageOffset] {
+			vms = append(vms, resources.CreateVirtualMachineWithID(id))
+		}
+
+		return vms, nil
+	}
+
+	// process lists virtual machines the same way as processor.ListResources with one list worker
+	process := func() []int {
+		p := &Processor{reporter: rep, listPage: listPage}
+		read := make(chan resource.Resource)
+		readDone := make(chan bool, 2*maxFailedPages)
+
+		go func() {
+			swg := sizedwaitgroup.New(2)
+			swg.Add()
+			go p.Process(context.Background(), read, readDone, &swg)
+			swg.Wait()
+			close(read)
+		}()
+
+		var ids []int
+		for vm := range read {
+			id, err := vm.ID()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			ids = append(ids, id)
+		}
+
+		return ids
+	}
+
+	ginkgo.Context("when a page in the middle cannot be listed", func() {
+		ginkgo.BeforeEach(func() {
+			pages = map[int][]int{1: {0, 1}, 2: {2, 3}, 3: {4}}
+			fails = map[int]bool{2: true}
+		})
+
+		ginkgo.It("should report the page and list the following pages", func(done ginkgo.Done) {
+			gomega.Expect(process()).To(gomega.ConsistOf(0, 1, 4))
+			gomega.Expect(rep.Skipped()).To(gomega.Equal(1))
+
+			close(done)
+		}, 1)
+	})
+
+	ginkgo.Context("when no page can be listed", func() {
+		ginkgo.BeforeEach(func() {
+			pages = map[int][]int{}
+			fails = map[int]bool{1: true, 2: true, 3: true, 4: true, 5: true, 6: true}
+		})
+
+		ginkgo.It("should stop listing", func(done ginkgo.Done) {
+			gomega.Expect(process()).To(gomega.BeEmpty())
+			gomega.Expect(rep.Skipped()).To(gomega.BeNumerically(">=", maxFailedPages))
+			gomega.Expect(calls).To(gomega.BeNumerically("<", len(fails)))
+
+			close(done)
+		}, 1)
+	})
+})
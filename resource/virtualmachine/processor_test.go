@@ -1,6 +1,7 @@
 package virtualmachine_test
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/dnaeon/go-vcr/recorder"
 	"github.com/goat-project/goat-one/constants"
 	"github.com/goat-project/goat-one/reader"
+	"github.com/goat-project/goat-one/report"
 	"github.com/goat-project/goat-one/util"
 	"github.com/onego-project/onego"
 	"github.com/onego-project/onego/errors"
@@ -40,6 +42,7 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 
 		proc *virtualmachine.Processor
 		read *reader.Reader
+		rep  *report.Reporter
 
 		channel chan resource.Resource
 
@@ -72,6 +75,9 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 		viper.SetDefault(constants.CfgOpennebulaTimeout, constants.OpenNebulaTimeout)
 
 		read = reader.CreateReader(client, rate.NewLimiter(rate.Every(time.Second/time.Duration(30)), 30))
+
+		rep, err = report.CreateReporter(constants.PolicyFailFast)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
 	ginkgo.AfterEach(func() {
@@ -84,7 +90,7 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 	ginkgo.Describe("create processor", func() {
 		ginkgo.Context("when reader is correct", func() {
 			ginkgo.It("should create processor", func() {
-				p := virtualmachine.CreateProcessor(read)
+				p := virtualmachine.CreateProcessor(read, rep)
 
 				gomega.Expect(p).NotTo(gomega.BeNil())
 			})
@@ -92,7 +98,7 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 
 		ginkgo.Context("when reader is not correct", func() {
 			ginkgo.It("should not create processor", func() {
-				p := virtualmachine.CreateProcessor(nil)
+				p := virtualmachine.CreateProcessor(nil, rep)
 
 				gomega.Expect(p).To(gomega.BeNil())
 
@@ -100,6 +106,17 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReaderNil))
 			})
 		})
+
+		ginkgo.Context("when reporter is not correct", func() {
+			ginkgo.It("should not create processor", func() {
+				p := virtualmachine.CreateProcessor(read, nil)
+
+				gomega.Expect(p).To(gomega.BeNil())
+
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal(constants.ErrCreateProcReporterNil))
+			})
+		})
 	})
 
 	ginkgo.Describe("process", func() {
@@ -109,13 +126,13 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 			})
 
 			ginkgo.It("should post resource to the channel", func(done ginkgo.Done) {
-				proc = virtualmachine.CreateProcessor(read)
+				proc = virtualmachine.CreateProcessor(read, rep)
 				channel = make(chan resource.Resource)
 				readDone := make(chan bool)
 
 				swg = sizedwaitgroup.New(3)
 				swg.Add()
-				go proc.Process(channel, readDone, &swg)
+				go proc.Process(context.Background(), channel, readDone, &swg)
 
 				for i := 0; i < 6; i++ {
 					gomega.Expect((<-channel).ID()).To(gomega.Equal(i))
@@ -133,7 +150,7 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 			})
 
 			ginkgo.It("should post resource to the channel", func(done ginkgo.Done) {
-				proc = virtualmachine.CreateProcessor(read)
+				proc = virtualmachine.CreateProcessor(read, rep)
 
 				channel = make(chan resource.Resource)
 
@@ -141,7 +158,7 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 
 				var wg sync.WaitGroup
 				wg.Add(1)
-				go proc.RetrieveInfo(channel, &wg, user)
+				go proc.RetrieveInfo(context.Background(), channel, &wg, user)
 
 				vm := <-channel
 				gomega.Expect(vm.ID()).To(gomega.Equal(0))
@@ -149,5 +166,28 @@ var _ = ginkgo.Describe("Virtual machine Processor tests", func() {
 				close(done)
 			}, 0.2)
 		})
+
+		ginkgo.Context("when virtual machine does not exist", func() {
+			ginkgo.BeforeEach(func() {
+				recName = "retrieveNotFound"
+			})
+
+			ginkgo.It("should skip virtual machine and report it", func() {
+				proc = virtualmachine.CreateProcessor(read, rep)
+
+				channel = make(chan resource.Resource)
+
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go proc.RetrieveInfo(context.Background(), channel, &wg, resources.CreateVirtualMachineWithID(1))
+
+				wg.Wait()
+
+				gomega.Expect(rep.Skipped()).To(gomega.Equal(1))
+				gomega.Expect(rep.Stopped()).To(gomega.BeTrue())
+				gomega.Expect(hook.LastEntry().Level).To(gomega.Equal(logrus.ErrorLevel))
+				gomega.Expect(hook.LastEntry().Message).To(gomega.Equal("error retrieve virtual machine info"))
+			})
+		})
 	})
 })
@@ -2,7 +2,10 @@ package reader
 
 import (
 	"context"
+	"time"
 
+	"github.com/beevik/etree"
+	"github.com/goat-project/goat-one/rpc"
 	"github.com/onego-project/onego/resources"
 
 	"github.com/goat-project/goat-one/resource"
@@ -26,6 +29,42 @@ type VMReaderForUser struct {
 	User *resources.User
 }
 
+// AccountingReader structure for a Reader which read history records of virtual machines
+// from OpenNebula accounting for a time window given by From and To.
+type AccountingReader struct {
+	From time.Time
+	To   time.Time
+}
+
+// ActiveVMsReader structure for a Reader which read active virtual machines of all users in one call.
+type ActiveVMsReader struct {
+}
+
+// VMsExceptDoneReader structure for a Reader which read virtual machines of all users in any state except DONE
+// in one call.
+type VMsExceptDoneReader struct {
+}
+
+// MonitoringReader structure for a Reader which read monitoring records of virtual machine by id.
+type MonitoringReader struct {
+	ID int
+}
+
+const (
+	accountingMethod = "one.vmpool.accounting"
+	monitoringMethod = "one.vm.monitoring"
+	poolMethod       = "one.vmpool.info"
+)
+
+// activeState is an OpenNebula state of active virtual machines.
+const activeState = 3
+
+// anyStateExceptDone is an OpenNebula state filter for virtual machines in any state except DONE.
+const anyStateExceptDone = -1
+
+// allResources is an OpenNebula filter flag for resources of all users.
+const allResources = -2
+
 // ReadResources reads an array of virtual machines.
 func (vmr *VMsReader) ReadResources(ctx context.Context, client *onego.Client) ([]resource.Resource, error) {
 	objs, err := client.VirtualMachineService.List(ctx, vmr.PageOffset, resource.PageSize, services.OwnershipFilterAll,
@@ -62,3 +101,52 @@ func (vmrfu *VMReaderForUser) ReadResourcesForUser(ctx context.Context,
 
 	return res, err
 }
+
+// ReadRPC reads history records of virtual machines from OpenNebula accounting for a time window.
+func (ar *AccountingReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, accountingMethod, allResources, accountingTime(ar.From), accountingTime(ar.To))
+	if err != nil {
+		return nil, err
+	}
+
+	return root.SelectElements("HISTORY"), nil
+}
+
+// ReadRPC reads active virtual machines of all users without paging.
+func (avr *ActiveVMsReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, poolMethod, allResources, -1, -1, activeState)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.SelectElements("VM"), nil
+}
+
+// ReadRPC reads virtual machines of all users in any state except DONE without paging.
+func (vedr *VMsExceptDoneReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, poolMethod, allResources, -1, -1, anyStateExceptDone)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.SelectElements("VM"), nil
+}
+
+// ReadRPC reads monitoring records of a virtual machine.
+func (mr *MonitoringReader) ReadRPC(ctx context.Context, client *rpc.Client) ([]*etree.Element, error) {
+	root, err := client.Call(ctx, monitoringMethod, mr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.ChildElements(), nil
+}
+
+// accountingTime returns time in seconds or -1 for a time window without a boundary.
+func accountingTime(t time.Time) int {
+	if t.IsZero() {
+		return -1
+	}
+
+	return int(t.Unix())
+}
@@ -2,12 +2,15 @@ package reader
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 
 	"github.com/goat-project/goat-one/util"
 
 	"github.com/goat-project/goat-one/constants"
 	"github.com/goat-project/goat-one/resource"
+	"github.com/goat-project/goat-one/rpc"
 	"github.com/onego-project/onego"
 	"github.com/onego-project/onego/errors"
 	"github.com/onsi/ginkgo"
@@ -221,3 +224,53 @@ var _ = ginkgo.Describe("Virtual Machine Reader tests", func() {
 		})
 	})
 })
+
+var _ = ginkgo.Describe("Virtual Machine Except Done Reader tests", func() {
+	const poweredOffVMResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<methodResponse><params><param><value><array><data>
+<value><boolean>1</boolean></value>
+<value><string>&lt;VM_POOL&gt;&lt;VM&gt;&lt;ID&gt;20&lt;/ID&gt;&lt;STATE&gt;8&lt;/STATE&gt;` +
+		`&lt;/VM&gt;&lt;/VM_POOL&gt;</string></value>
+<value><i4>0</i4></value>
+</data></array></value></param></params></methodResponse>`
+
+	var (
+		server  *httptest.Server
+		request string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			request = string(body)
+
+			_, err = w.Write([]byte(poweredOffVMResponse))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.Describe("read vms in any state except done from OpenNebula", func() {
+		ginkgo.Context("when virtual machine is powered off", func() {
+			ginkgo.It("should ask for any state except done and return the virtual machine", func() {
+				client := rpc.CreateClient(server.URL, constants.Token, &http.Client{})
+
+				vms, err := (&VMsExceptDoneReader{}).ReadRPC(context.TODO(), client)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(vms).To(gomega.HaveLen(1))
+				gomega.Expect(vms[0].FindElement("STATE").Text()).To(gomega.Equal("8"))
+
+				gomega.Expect(request).To(gomega.ContainSubstring(
+					"<param><value><int>-2</int></value></param>" +
+						"<param><value><int>-1</int></value></param>" +
+						"<param><value><int>-1</int></value></param>" +
+						"<param><value><int>-1</int></value></param>" +
+						"</params>"))
+			})
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/beevik/etree"
+)
+
+// Client structure to call OpenNebula XML-RPC methods which are not provided by onego.
+type Client struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// CreateClient creates client with OpenNebula endpoint, token (username:password) and HTTP client.
+func CreateClient(endpoint, token string, client *http.Client) *Client {
+	return &Client{
+		endpoint: endpoint,
+		token:    token,
+		client:   client,
+	}
+}
+
+// Call calls OpenNebula method with given parameters (string, int or bool). It returns root element
+// of XML document which OpenNebula returns on success and an error with OpenNebula message otherwise.
+func (c *Client) Call(ctx context.Context, method string, params ...interface{}) (*etree.Element, error) {
+	body, err := createRequest(method, append([]interface{}{c.token}, params...))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "text/xml")
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected HTTP status %s", method, resp.Status)
+	}
+
+	return parseResponse(method, resp.Body)
+}
+
+func createRequest(method string, params []interface{}) ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	call := doc.CreateElement("methodCall")
+	call.CreateElement("methodName").SetText(method)
+
+	ps := call.CreateElement("params")
+	for _, param := range params {
+		value := ps.CreateElement("param").CreateElement("value")
+
+		switch p := param.(type) {
+		case string:
+			value.CreateElement("string").SetText(p)
+		case int:
+			value.CreateElement("int").SetText(strconv.Itoa(p))
+		case bool:
+			b := "0"
+			if p {
+				b = "1"
+			}
+
+			value.CreateElement("boolean").SetText(b)
+		default:
+			return nil, fmt.Errorf("%s: unsupported parameter type %T", method, param)
+		}
+	}
+
+	return doc.WriteToBytes()
+}
+
+// parseResponse reads OpenNebula response which is an array of success flag and XML document
+// or error message.
+func parseResponse(method string, r io.Reader) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if _, err := doc.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	if fault := doc.FindElement("/methodResponse/fault"); fault != nil {
+		return nil, fmt.Errorf("%s: %s", method, value(fault.FindElement(".//member[name='faultString']/value")))
+	}
+
+	values := doc.FindElements("/methodResponse/params/param/value/array/data/value")
+	if len(values) < 2 {
+		return nil, fmt.Errorf("%s: unexpected response", method)
+	}
+
+	if value(values[0]) != "1" {
+		return nil, fmt.Errorf("%s: %s", method, value(values[1]))
+	}
+
+	result := etree.NewDocument()
+	if err := result.ReadFromString(value(values[1])); err != nil {
+		return nil, err
+	}
+
+	if result.Root() == nil {
+		return nil, fmt.Errorf("%s: no XML document in response", method)
+	}
+
+	return result.Root(), nil
+}
+
+// value returns text of XML-RPC value. The value without type element is a string.
+func value(e *etree.Element) string {
+	if e == nil {
+		return ""
+	}
+
+	if len(e.ChildElements()) > 0 {
+		return e.ChildElements()[0].Text()
+	}
+
+	return e.Text()
+}
@@ -0,0 +1,140 @@
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+const (
+	token = "oneadmin:opennebula"
+
+	successResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<methodResponse><params><param><value><array><data>
+<value><boolean>1</boolean></value>
+<value><string>&lt;HISTORY_RECORDS&gt;&lt;HISTORY&gt;&lt;OID&gt;1&lt;/OID&gt;` +
+		`&lt;/HISTORY&gt;&lt;/HISTORY_RECORDS&gt;</string></value>
+<value><i4>0</i4></value>
+</data></array></value></param></params></methodResponse>`
+
+	failureResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<methodResponse><params><param><value><array><data>
+<value><boolean>0</boolean></value>
+<value><string>[one.vmpool.accounting] User couldn't be authenticated, aborting call.</string></value>
+<value><i4>256</i4></value>
+</data></array></value></param></params></methodResponse>`
+
+	faultResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<methodResponse><fault><value><struct>
+<member><name>faultCode</name><value><int>-501</int></value></member>
+<member><name>faultString</name><value><string>No such method 'one.vmpool.unknown'</string></value></member>
+</struct></value></fault></methodResponse>`
+)
+
+var _ = ginkgo.Describe("RPC client tests", func() {
+	var (
+		server   *httptest.Server
+		request  string
+		response string
+		status   int
+	)
+
+	ginkgo.BeforeEach(func() {
+		request = ""
+		response = successResponse
+		status = http.StatusOK
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			request = string(body)
+
+			w.WriteHeader(status)
+			_, err = w.Write([]byte(response))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.Describe("call method", func() {
+		ginkgo.Context("when OpenNebula returns XML document", func() {
+			ginkgo.It("should send token with parameters and return root element", func() {
+				client := CreateClient(server.URL, token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.accounting", -2, -1, 1541050000)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(root.Tag).To(gomega.Equal("HISTORY_RECORDS"))
+				gomega.Expect(root.FindElement("HISTORY/OID").Text()).To(gomega.Equal("1"))
+
+				gomega.Expect(request).To(gomega.ContainSubstring(
+					"<methodCall><methodName>one.vmpool.accounting</methodName><params>" +
+						"<param><value><string>oneadmin:opennebula</string></value></param>" +
+						"<param><value><int>-2</int></value></param>" +
+						"<param><value><int>-1</int></value></param>" +
+						"<param><value><int>1541050000</int></value></param>" +
+						"</params></methodCall>"))
+			})
+		})
+
+		ginkgo.Context("when OpenNebula returns an error", func() {
+			ginkgo.It("should return the error", func() {
+				response = failureResponse
+				client := CreateClient(server.URL, token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.accounting", -2, -1, -1)
+				gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("User couldn't be authenticated")))
+				gomega.Expect(root).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.Context("when OpenNebula returns a fault", func() {
+			ginkgo.It("should return the fault string", func() {
+				response = faultResponse
+				client := CreateClient(server.URL, token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.unknown")
+				gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("No such method")))
+				gomega.Expect(root).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.Context("when HTTP status is not OK", func() {
+			ginkgo.It("should return an error", func() {
+				status = http.StatusInternalServerError
+				client := CreateClient(server.URL, token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.accounting", -2, -1, -1)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(root).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.Context("when parameter type is not supported", func() {
+			ginkgo.It("should return an error", func() {
+				client := CreateClient(server.URL, token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.accounting", 1.5)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(root).To(gomega.BeNil())
+				gomega.Expect(request).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when endpoint is wrong", func() {
+			ginkgo.It("should return an error", func() {
+				client := CreateClient("http://127.0.0.1:1/RPC2", token, &http.Client{})
+
+				root, err := client.Call(context.TODO(), "one.vmpool.accounting", -2, -1, -1)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(root).To(gomega.BeNil())
+			})
+		})
+	})
+})
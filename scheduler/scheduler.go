@@ -0,0 +1,191 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goat-project/goat-one/state"
+	"github.com/robfig/cron"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RunFunc accounts a resource for a window. It returns true when the resource was accounted successfully.
+type RunFunc func(context.Context, Window) bool
+
+// Status represents schedule and run times of a resource. The last success is the end of the last successful window.
+type Status struct {
+	Schedule    string     `json:"schedule"`
+	Window      string     `json:"window"`
+	Running     bool       `json:"running"`
+	LastRun     time.Time  `json:"last-run"`
+	LastSuccess *time.Time `json:"last-success,omitempty"`
+	NextRun     time.Time  `json:"next-run"`
+}
+
+// Scheduler runs accounting of resources on cron schedules. Runs of the same resource never overlap,
+// a run which is missed since the previous run is still running is skipped.
+type Scheduler struct {
+	store      *state.Store
+	identifier string
+	jobs       []*job
+}
+
+type job struct {
+	name     string
+	schedule cron.Schedule
+	run      RunFunc
+
+	mutex  sync.Mutex
+	status Status
+}
+
+// CreateScheduler creates Scheduler which keeps the end of the last successful window of every resource
+// as checkpoint of the resource accounted with a given identifier in a store, the same checkpoint
+// incremental accounting starts at.
+func CreateScheduler(store *state.Store, identifier string) *Scheduler {
+	if store == nil {
+		log.WithFields(log.Fields{}).Error("error create Scheduler when store is nil")
+		return nil
+	}
+
+	return &Scheduler{
+		store:      store,
+		identifier: identifier,
+	}
+}
+
+// Add adds accounting of a resource run on a cron schedule (e.g. @hourly or 0 2 * * *) for a window
+// of a given period (e.g. 1h or 1d).
+func (s *Scheduler) Add(name, spec, period string, run RunFunc) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("wrong format of schedule %s: %v", spec, err)
+	}
+
+	if _, err = nextWindow(nil, period, time.Now()); err != nil {
+		return err
+	}
+
+	checkpoint, err := s.store.LoadCheckpoint(name, s.identifier)
+	if err != nil {
+		return err
+	}
+
+	var last *time.Time
+	if checkpoint != nil {
+		last = &checkpoint.RecordsTo
+	}
+
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		schedule: schedule,
+		run:      run,
+		status: Status{
+			Schedule:    spec,
+			Window:      period,
+			LastSuccess: last,
+		},
+	})
+
+	return nil
+}
+
+// Run runs accounting of all resources on their schedules until the context is canceled.
+// It returns when the running accountings finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+
+	wg.Wait()
+}
+
+// Status returns schedule and run times of all resources.
+func (s *Scheduler) Status() map[string]Status {
+	statuses := make(map[string]Status, len(s.jobs))
+
+	for _, j := range s.jobs {
+		j.mutex.Lock()
+		statuses[j.name] = j.status
+		j.mutex.Unlock()
+	}
+
+	return statuses
+}
+
+// ServeHTTP writes schedule and run times of all resources as JSON.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(s.Status()); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("error write scheduler status")
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+
+		j.mutex.Lock()
+		j.status.NextRun = next
+		j.mutex.Unlock()
+
+		log.WithFields(log.Fields{"resource": j.name, "next-run": next}).Info("accounting scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runJob(ctx, j, next)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j *job, now time.Time) {
+	j.mutex.Lock()
+	window, err := nextWindow(j.status.LastSuccess, j.status.Window, now)
+	j.status.LastRun = now
+	j.status.Running = err == nil
+	j.mutex.Unlock()
+
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": j.name}).Error("error create window")
+		return
+	}
+
+	log.WithFields(log.Fields{"resource": j.name, "from": window.From, "to": window.To}).Info("accounting started")
+
+	ok := j.run(ctx, window)
+
+	j.mutex.Lock()
+	j.status.Running = false
+	if ok {
+		j.status.LastSuccess = &window.To
+	}
+	j.mutex.Unlock()
+
+	if !ok {
+		log.WithFields(log.Fields{"resource": j.name, "from": window.From, "to": window.To}).Error("accounting failed")
+		return
+	}
+
+	if err = s.store.SaveCheckpoint(j.name, s.identifier, window.To); err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": j.name}).Error("error save checkpoint")
+	}
+
+	log.WithFields(log.Fields{"resource": j.name, "from": window.From, "to": window.To}).Info("accounting finished")
+}
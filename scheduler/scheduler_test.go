@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/goat-project/goat-one/state"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Scheduler tests", func() {
+	var (
+		dir   string
+		store *state.Store
+		s     *Scheduler
+		err   error
+	)
+
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ginkgo.BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "goat-one-state")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		store, err = state.CreateStore(dir)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		s = CreateScheduler(store, "goat")
+	})
+
+	ginkgo.AfterEach(func() {
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	ginkgo.Describe("create scheduler", func() {
+		ginkgo.Context("when store is not set", func() {
+			ginkgo.It("should not create scheduler", func() {
+				gomega.Expect(CreateScheduler(nil, "goat")).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Describe("add resource", func() {
+		ginkgo.Context("when schedule is wrong", func() {
+			ginkgo.It("should return error", func() {
+				gomega.Expect(s.Add("vm", "every hour", "1h", nil)).NotTo(gomega.Succeed())
+			})
+		})
+
+		ginkgo.Context("when window is wrong", func() {
+			ginkgo.It("should return error", func() {
+				gomega.Expect(s.Add("vm", "@hourly", "hour", nil)).NotTo(gomega.Succeed())
+			})
+		})
+
+		ginkgo.Context("when resource was accounted", func() {
+			ginkgo.It("should expose the end of the last successful window from the checkpoint", func() {
+				gomega.Expect(store.SaveCheckpoint("vm", "goat", now)).To(gomega.Succeed())
+
+				gomega.Expect(s.Add("vm", "@hourly", "1h", nil)).To(gomega.Succeed())
+
+				status := s.Status()["vm"]
+				gomega.Expect(status.Schedule).To(gomega.Equal("@hourly"))
+				gomega.Expect(status.LastSuccess.Equal(now)).To(gomega.BeTrue())
+			})
+		})
+	})
+
+	ginkgo.Describe("next window", func() {
+		ginkgo.Context("when resource was not accounted", func() {
+			ginkgo.It("should return the period before the run", func() {
+				w, err := nextWindow(nil, "1h", now)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(w).To(gomega.Equal(Window{From: now.Add(-time.Hour), To: now}))
+			})
+		})
+
+		ginkgo.Context("when the last successful window ends within the period", func() {
+			ginkgo.It("should return the period before the run", func() {
+				last := now.Add(-time.Minute)
+
+				w, err := nextWindow(&last, "1h", now)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(w).To(gomega.Equal(Window{From: now.Add(-time.Hour), To: now}))
+			})
+		})
+
+		ginkgo.Context("when the last successful window ends before the period", func() {
+			ginkgo.It("should return window from the end of the last successful window", func() {
+				last := now.Add(-3 * time.Hour)
+
+				w, err := nextWindow(&last, "1h", now)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(w).To(gomega.Equal(Window{From: now.Add(-3 * time.Hour), To: now}))
+			})
+		})
+	})
+
+	ginkgo.Describe("run", func() {
+		ginkgo.Context("when accounting succeeds", func() {
+			ginkgo.It("should save the end of the window as checkpoint and schedule the next run", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				runs := make(chan Window, 1)
+
+				gomega.Expect(s.Add("vm", "@every 1s", "1h", func(_ context.Context, w Window) bool {
+					cancel()
+					runs <- w
+					return true
+				})).To(gomega.Succeed())
+
+				s.Run(ctx)
+
+				w := <-runs
+				checkpoint, err := store.LoadCheckpoint("vm", "goat")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(checkpoint).NotTo(gomega.BeNil())
+				gomega.Expect(checkpoint.RecordsTo.Equal(w.To)).To(gomega.BeTrue())
+
+				status := s.Status()["vm"]
+				gomega.Expect(status.Running).To(gomega.BeFalse())
+				gomega.Expect(status.LastRun.Equal(w.To)).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.Context("when accounting fails", func() {
+			ginkgo.It("should not save the checkpoint", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+
+				gomega.Expect(s.Add("vm", "@every 1s", "1h", func(context.Context, Window) bool {
+					cancel()
+					return false
+				})).To(gomega.Succeed())
+
+				s.Run(ctx)
+
+				checkpoint, err := store.LoadCheckpoint("vm", "goat")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(checkpoint).To(gomega.BeNil())
+				gomega.Expect(s.Status()["vm"].LastSuccess).To(gomega.BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/karrick/tparse/v2"
+)
+
+// Window represents time window resources are accounted for.
+type Window struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// nextWindow returns window of a run at a given time. The window is the period before the run. It is extended
+// to the end of the last successful window when the previous runs failed or did not run, so no usage is lost.
+func nextWindow(last *time.Time, period string, now time.Time) (Window, error) {
+	from, err := tparse.AddDuration(now, "-"+period)
+	if err != nil {
+		return Window{}, fmt.Errorf("wrong format of window %s: %v", period, err)
+	}
+
+	if last != nil && last.Before(from) {
+		from = *last
+	}
+
+	return Window{From: from, To: now}, nil
+}
@@ -0,0 +1,169 @@
+package secret
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/spf13/viper"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces secrets in logs.
+const Redacted = "[redacted]"
+
+// minLength is the minimal length of a registered secret, shorter secrets would redact common words in logs.
+const minLength = 4
+
+// keys of configuration with secrets
+var keys = map[string]bool{
+	constants.CfgOpennebulaSecret: true,
+}
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Hook is logrus hook which redacts registered secrets in messages and fields of log entries.
+type Hook struct{}
+
+// IsSecret returns true when a configuration key holds a secret.
+func IsSecret(key string) bool {
+	return keys[key]
+}
+
+// Value returns value of a configuration key for logs. Value of a key with a secret is redacted.
+func Value(key string, value interface{}) interface{} {
+	if IsSecret(key) && value != nil && value != "" {
+		return Redacted
+	}
+
+	return value
+}
+
+// Register registers a secret, so it is redacted in logs. Password of a secret in form username:password
+// is registered as well.
+func Register(secret string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	values := []string{secret}
+	if i := strings.Index(secret, ":"); i >= 0 {
+		values = append(values, secret[i+1:])
+	}
+
+	for _, value := range values {
+		if len(value) >= minLength && !contains(secrets, value) {
+			secrets = append(secrets, value)
+		}
+	}
+}
+
+// Redact replaces registered secrets in a string.
+func Redact(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+	}
+
+	return s
+}
+
+// ReadFile reads a secret from a file in the format of OpenNebula one_auth file, i.e. username:password
+// on the first line which is not empty.
+func ReadFile(path string) (string, error) {
+	f, err := os.Open(path) // nolint: gosec
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.WithFields(logrus.Fields{"error": err, "path": path}).Error("error close secret file")
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !strings.Contains(line, ":") {
+			return "", fmt.Errorf("secret in %s is not in form username:password", path)
+		}
+
+		return line, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no secret in %s", path)
+}
+
+// OpenNebula returns OpenNebula secret from configuration. When it is not set, the secret is read from
+// the secret file or from the one_auth file given by ONE_AUTH environment variable. It returns empty string
+// when there is no secret.
+func OpenNebula() (string, error) {
+	if s := viper.GetString(constants.CfgOpennebulaSecret); s != "" {
+		return s, nil
+	}
+
+	path := viper.GetString(constants.CfgOpennebulaSecretFile)
+	if path == "" {
+		path = os.Getenv(constants.EnvOneAuth)
+	}
+
+	if path == "" {
+		return "", nil
+	}
+
+	return ReadFile(path)
+}
+
+// Levels returns all levels, so every log entry is redacted.
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts registered secrets in message and in string and error fields of a log entry.
+func (Hook) Fire(entry *logrus.Entry) error {
+	entry.Message = Redact(entry.Message)
+
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = Redact(v)
+		case error:
+			if redacted := Redact(v.Error()); redacted != v.Error() {
+				entry.Data[key] = redacted
+			}
+		case fmt.Stringer:
+			if redacted := Redact(v.String()); redacted != v.String() {
+				entry.Data[key] = redacted
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var _ = ginkgo.Describe("Secret tests", func() {
+	var dir string
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		gomega.Expect(ioutil.WriteFile(path, []byte(content), 0600)).To(gomega.Succeed())
+
+		return path
+	}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "goat-one-secret")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		viper.Reset()
+		mu.Lock()
+		secrets = nil
+		mu.Unlock()
+	})
+
+	ginkgo.AfterEach(func() {
+		viper.Reset()
+		gomega.Expect(os.Unsetenv(constants.EnvOneAuth)).To(gomega.Succeed())
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	ginkgo.Describe("read secret file", func() {
+		ginkgo.Context("when file is in one_auth format", func() {
+			ginkgo.It("should return the first line which is not empty", func() {
+				secret, err := ReadFile(write("one_auth", "\n  oneadmin:opennebula  \nsecond:line\n"))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(secret).To(gomega.Equal("oneadmin:opennebula"))
+			})
+		})
+
+		ginkgo.Context("when secret is not in form username:password", func() {
+			ginkgo.It("should return error without the content of the file", func() {
+				_, err := ReadFile(write("one_auth", "opennebula\n"))
+
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(err.Error()).NotTo(gomega.ContainSubstring("opennebula\n"))
+			})
+		})
+
+		ginkgo.Context("when file is empty", func() {
+			ginkgo.It("should return error", func() {
+				_, err := ReadFile(write("one_auth", ""))
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("OpenNebula secret", func() {
+		ginkgo.Context("when secret is set", func() {
+			ginkgo.It("should return it", func() {
+				viper.Set(constants.CfgOpennebulaSecret, "oneadmin:set")
+				viper.Set(constants.CfgOpennebulaSecretFile, write("secret", "oneadmin:file"))
+
+				gomega.Expect(OpenNebula()).To(gomega.Equal("oneadmin:set"))
+			})
+		})
+
+		ginkgo.Context("when secret file is set", func() {
+			ginkgo.It("should read the secret from the file", func() {
+				viper.Set(constants.CfgOpennebulaSecretFile, write("secret", "oneadmin:file"))
+				gomega.Expect(os.Setenv(constants.EnvOneAuth, write("one_auth", "oneadmin:one_auth"))).To(
+					gomega.Succeed())
+
+				gomega.Expect(OpenNebula()).To(gomega.Equal("oneadmin:file"))
+			})
+		})
+
+		ginkgo.Context("when only ONE_AUTH is set", func() {
+			ginkgo.It("should read the secret from the one_auth file", func() {
+				gomega.Expect(os.Setenv(constants.EnvOneAuth, write("one_auth", "oneadmin:one_auth"))).To(
+					gomega.Succeed())
+
+				gomega.Expect(OpenNebula()).To(gomega.Equal("oneadmin:one_auth"))
+			})
+		})
+
+		ginkgo.Context("when no secret is set", func() {
+			ginkgo.It("should return empty string", func() {
+				gomega.Expect(OpenNebula()).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("redact secrets", func() {
+		ginkgo.It("should redact registered secrets and passwords", func() {
+			Register("oneadmin:opennebula")
+			Register("a:b")
+
+			gomega.Expect(Redact("login oneadmin:opennebula failed")).To(gomega.Equal("login [redacted] failed"))
+			gomega.Expect(Redact("password opennebula is wrong")).To(gomega.Equal("password [redacted] is wrong"))
+			gomega.Expect(Redact("a:b")).To(gomega.Equal("a:b"))
+		})
+
+		ginkgo.It("should redact value of a configuration key with a secret", func() {
+			gomega.Expect(Value(constants.CfgOpennebulaSecret, "oneadmin:opennebula")).To(gomega.Equal(Redacted))
+			gomega.Expect(Value(constants.CfgOpennebulaSecret, "")).To(gomega.BeEmpty())
+			gomega.Expect(Value(constants.CfgOpennebulaEndpoint, "http://one:2633/RPC2")).To(
+				gomega.Equal("http://one:2633/RPC2"))
+		})
+
+		ginkgo.It("should redact messages and fields of log entries", func() {
+			Register("oneadmin:opennebula")
+
+			var buf bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&buf)
+			log.AddHook(Hook{})
+
+			log.WithFields(logrus.Fields{
+				"error": errors.New("wrong secret oneadmin:opennebula"),
+				"value": "opennebula",
+			}).Error("login with opennebula failed")
+
+			gomega.Expect(buf.String()).NotTo(gomega.ContainSubstring("opennebula"))
+			gomega.Expect(buf.String()).To(gomega.ContainSubstring(Redacted))
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package state
+
+import "time"
+
+// Checkpoint represents the end of the last window a resource was successfully accounted for.
+type Checkpoint struct {
+	RecordsTo time.Time `json:"records-to"`
+}
+
+const checkpointPrefix = "checkpoint-"
+
+// LoadCheckpoint returns checkpoint of a resource accounted by an instance with a given identifier. It returns nil
+// when the resource was not accounted successfully yet.
+func (s *Store) LoadCheckpoint(resource, identifier string) (*Checkpoint, error) {
+	var c Checkpoint
+
+	stored, err := s.Load(checkpointName(resource, identifier), &c)
+	if err != nil || !stored {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// SaveCheckpoint stores checkpoint of a resource accounted by an instance with a given identifier.
+func (s *Store) SaveCheckpoint(resource, identifier string, recordsTo time.Time) error {
+	return s.Save(checkpointName(resource, identifier), Checkpoint{RecordsTo: recordsTo})
+}
+
+func checkpointName(resource, identifier string) string {
+	return checkpointPrefix + resource + "-" + identifier
+}
@@ -0,0 +1,76 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Store keeps state of accounting between runs in a local directory. Every state is a JSON file.
+type Store struct {
+	dir string
+}
+
+const stateExtension = ".json"
+
+// CreateStore creates store in a given directory.
+func CreateStore(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no state directory in configuration")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		dir: dir,
+	}, nil
+}
+
+// Load reads state stored under a given name to v. It returns false when no state is stored.
+func (s *Store) Load(name string, v interface{}) (bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, name+stateExtension))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Save stores v under a given name. The state is written to a temporary file which replaces the stored one,
+// so the stored state is never partially written.
+func (s *Store) Save(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, name+"-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name+stateExtension))
+}
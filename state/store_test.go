@@ -0,0 +1,128 @@
+package state_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goat-project/goat-one/state"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+type testState struct {
+	RecordsTo time.Time `json:"records-to"`
+}
+
+var _ = ginkgo.Describe("Store tests", func() {
+	var (
+		dir   string
+		store *state.Store
+		err   error
+	)
+
+	ginkgo.BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "goat-one-state")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		store, err = state.CreateStore(dir)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	ginkgo.AfterEach(func() {
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	ginkgo.Describe("create store", func() {
+		ginkgo.Context("when directory is not set", func() {
+			ginkgo.It("should not create store", func() {
+				s, err := state.CreateStore("")
+
+				gomega.Expect(s).To(gomega.BeNil())
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("load state", func() {
+		ginkgo.Context("when state is not stored", func() {
+			ginkgo.It("should return false", func() {
+				stored, err := store.Load("vm", &testState{})
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(stored).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.Context("when state is stored", func() {
+			ginkgo.It("should return the last saved state", func() {
+				first := testState{RecordsTo: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)}
+				second := testState{RecordsTo: time.Date(2020, 3, 1, 13, 0, 0, 0, time.UTC)}
+				gomega.Expect(store.Save("vm", first)).To(gomega.Succeed())
+				gomega.Expect(store.Save("vm", second)).To(gomega.Succeed())
+
+				var s testState
+				stored, err := store.Load("vm", &s)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(stored).To(gomega.BeTrue())
+				gomega.Expect(s.RecordsTo.Equal(second.RecordsTo)).To(gomega.BeTrue())
+			})
+
+			ginkgo.It("should not leave temporary files", func() {
+				gomega.Expect(store.Save("vm", testState{})).To(gomega.Succeed())
+
+				files, err := filepath.Glob(filepath.Join(dir, "*"))
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(files).To(gomega.Equal([]string{filepath.Join(dir, "vm.json")}))
+			})
+		})
+
+		ginkgo.Context("when state is corrupted", func() {
+			ginkgo.It("should return error", func() {
+				gomega.Expect(ioutil.WriteFile(filepath.Join(dir, "vm.json"), []byte("{"), 0600)).To(gomega.Succeed())
+
+				_, err := store.Load("vm", &testState{})
+
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("checkpoint", func() {
+		recordsTo := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		ginkgo.Context("when resource was not accounted", func() {
+			ginkgo.It("should return no checkpoint", func() {
+				c, err := store.LoadCheckpoint("vm", "goat")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(c).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.Context("when resource was accounted", func() {
+			ginkgo.It("should return the end of the last window", func() {
+				gomega.Expect(store.SaveCheckpoint("vm", "goat", recordsTo)).To(gomega.Succeed())
+
+				c, err := store.LoadCheckpoint("vm", "goat")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(c.RecordsTo.Equal(recordsTo)).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.Context("when resource was accounted by other instance", func() {
+			ginkgo.It("should return no checkpoint", func() {
+				gomega.Expect(store.SaveCheckpoint("vm", "other", recordsTo)).To(gomega.Succeed())
+
+				c, err := store.LoadCheckpoint("vm", "goat")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(c).To(gomega.BeNil())
+			})
+		})
+	})
+})
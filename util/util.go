@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/golang/protobuf/ptypes/wrappers"
@@ -76,3 +79,16 @@ func IsPublicIPv4(ip net.IP) bool {
 
 	return false
 }
+
+// RecordID returns UUID (version 5) of a record derived from OpenNebula endpoint and parts identifying the record.
+// The same endpoint and parts always give the same UUID, so a record sent again can be de-duplicated.
+func RecordID(endpoint string, parts ...interface{}) string {
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		names = append(names, fmt.Sprint(part))
+	}
+
+	namespace := uuid.NewSHA1(uuid.NameSpaceURL, []byte(endpoint))
+
+	return uuid.NewSHA1(namespace, []byte(strings.Join(names, "/"))).String()
+}
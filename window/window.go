@@ -0,0 +1,57 @@
+package window
+
+import (
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/karrick/tparse/v2"
+	"github.com/spf13/viper"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Create returns times from/to records are filtered by. The window is given by times from/to or by a period
+// ending now from configuration, time to is now when it is not set.
+func Create() (time.Time, time.Time) {
+	recordsFrom := viper.GetTime(constants.CfgRecordsFrom)
+	recordsTo := viper.GetTime(constants.CfgRecordsTo)
+
+	periodStr := viper.GetString(constants.CfgRecordsForPeriod)
+	period, err := tparse.AddDuration(time.Time{}, periodStr)
+	if err != nil {
+		log.WithFields(log.Fields{"period": periodStr}).Error("wrong format of period")
+		period = time.Time{}
+	}
+
+	if (!recordsFrom.Equal(time.Time{}) || !recordsTo.Equal(time.Time{})) && !period.Equal(time.Time{}) {
+		log.WithFields(log.Fields{
+			"records-from": recordsFrom, "records-to": recordsTo, "period": periodStr,
+		}).Fatal("cannot filter records from/to and records for a period in the same time")
+	}
+
+	if !period.Equal(time.Time{}) {
+		now := time.Now()
+		recFrom, err := tparse.AddDuration(now, "-"+periodStr)
+		if err != nil {
+			log.WithFields(log.Fields{"period": periodStr}).Error("wrong format of period")
+		}
+
+		log.WithFields(log.Fields{
+			"record-from": recFrom, "record-to": now, "period": periodStr,
+		}).Debug("filter set by a period")
+
+		return recFrom, now
+	}
+
+	if recordsTo.Equal(time.Time{}) {
+		now := time.Now()
+
+		log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": now}).Debug("filter from a given time to now")
+
+		return recordsFrom, now
+	}
+
+	log.WithFields(log.Fields{"record-from": recordsFrom, "record-to": recordsTo}).Debug("filter set by times from and to")
+
+	return recordsFrom, recordsTo
+}
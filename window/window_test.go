@@ -0,0 +1,66 @@
+package window
+
+import (
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = ginkgo.Describe("Window tests", func() {
+	ginkgo.BeforeEach(func() {
+		viper.Reset()
+	})
+
+	ginkgo.AfterEach(func() {
+		viper.Reset()
+	})
+
+	ginkgo.Context("when no values are set", func() {
+		ginkgo.It("should return window from the beginning to now", func() {
+			from, to := Create()
+
+			gomega.Expect(from).To(gomega.Equal(time.Time{}))
+			gomega.Expect(to).To(gomega.BeTemporally("~", time.Now(), time.Minute))
+		})
+	})
+
+	ginkgo.Context("when times from and to are set", func() {
+		ginkgo.It("should return window given by the times", func() {
+			dateFrom := time.Now().Add(-48 * time.Hour)
+			dateTo := time.Now().Add(-24 * time.Hour)
+			viper.Set(constants.CfgRecordsFrom, dateFrom)
+			viper.Set(constants.CfgRecordsTo, dateTo)
+
+			from, to := Create()
+
+			gomega.Expect(from).To(gomega.Equal(dateFrom))
+			gomega.Expect(to).To(gomega.Equal(dateTo))
+		})
+	})
+
+	ginkgo.Context("when time from is set", func() {
+		ginkgo.It("should return window from the time to now", func() {
+			dateFrom := time.Now().Add(-48 * time.Hour)
+			viper.Set(constants.CfgRecordsFrom, dateFrom)
+
+			from, to := Create()
+
+			gomega.Expect(from).To(gomega.Equal(dateFrom))
+			gomega.Expect(to).To(gomega.BeTemporally("~", time.Now(), time.Minute))
+		})
+	})
+
+	ginkgo.Context("when period is set", func() {
+		ginkgo.It("should return window for the period ending now", func() {
+			viper.Set(constants.CfgRecordsForPeriod, "24h")
+
+			from, to := Create()
+
+			gomega.Expect(to).To(gomega.BeTemporally("~", time.Now(), time.Minute))
+			gomega.Expect(to.Sub(from)).To(gomega.BeNumerically("~", 24*time.Hour, time.Hour))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package writer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Credentials returns gRPC dial option securing connection to Goat server. The connection is secured by TLS
+// when a CA bundle, a client certificate and key or a server name is set by configuration, it is insecure otherwise.
+func Credentials() (grpc.DialOption, error) {
+	ca := viper.GetString(constants.CfgTLSCA)
+	cert := viper.GetString(constants.CfgTLSCert)
+	key := viper.GetString(constants.CfgTLSKey)
+	serverName := viper.GetString(constants.CfgTLSServerName)
+
+	if ca == "" && cert == "" && key == "" && serverName == "" {
+		return grpc.WithInsecure(), nil
+	}
+
+	config := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if ca != "" {
+		pool, err := loadCertPool(ca)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = pool
+	}
+
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return nil, fmt.Errorf("both client certificate and client key have to be set for mutual TLS")
+		}
+
+		certificate, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
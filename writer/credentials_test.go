@@ -0,0 +1,323 @@
+package writer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "github.com/goat-project/goat-proto-go"
+)
+
+const testServerName = "goat-server"
+
+type testAccountingServer struct {
+	identifiers []string
+}
+
+func (s *testAccountingServer) ProcessVms(stream pb.AccountingService_ProcessVmsServer) error {
+	for {
+		data, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&empty.Empty{})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		s.identifiers = append(s.identifiers, data.GetIdentifier())
+	}
+}
+
+func (s *testAccountingServer) ProcessIps(stream pb.AccountingService_ProcessIpsServer) error {
+	return stream.SendAndClose(&empty.Empty{})
+}
+
+func (s *testAccountingServer) ProcessStorages(stream pb.AccountingService_ProcessStoragesServer) error {
+	return stream.SendAndClose(&empty.Empty{})
+}
+
+type testAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func createTestAuthority() *testAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goat test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return &testAuthority{cert: cert, key: key}
+}
+
+func (a *testAuthority) issue(name string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (a *testAuthority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+
+	return pool
+}
+
+func writePEM(path, blockType string, der []byte) {
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), filePermissions)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func writeCertificate(dir, name string, cert tls.Certificate) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+
+	der, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	writePEM(certPath, "CERTIFICATE", cert.Certificate[0])
+	writePEM(keyPath, "EC PRIVATE KEY", der)
+
+	return certPath, keyPath
+}
+
+func startTestServer(config *tls.Config, srv *testAccountingServer) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(config)))
+	pb.RegisterAccountingServiceServer(server, srv)
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	return server, lis.Addr().String()
+}
+
+func sendTestIdentifier(endpoint string) error {
+	creds, err := Credentials()
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.Dial(endpoint, creds)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := pb.NewAccountingServiceClient(conn).ProcessVms(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = stream.Send(&pb.VmData{Data: &pb.VmData_Identifier{Identifier: "goat"}}); err != nil {
+		return err
+	}
+
+	_, err = stream.CloseAndRecv()
+
+	return err
+}
+
+var _ = ginkgo.Describe("Credentials tests", func() {
+	var (
+		dir        string
+		authority  *testAuthority
+		caPath     string
+		certPath   string
+		keyPath    string
+		srv        *testAccountingServer
+		server     *grpc.Server
+		endpoint   string
+		clientAuth tls.ClientAuthType
+	)
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "goat-tls")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		authority = createTestAuthority()
+		caPath = filepath.Join(dir, "ca.crt")
+		writePEM(caPath, "CERTIFICATE", authority.cert.Raw)
+		certPath, keyPath = writeCertificate(dir, "client", authority.issue("goat-one", x509.ExtKeyUsageClientAuth))
+
+		clientAuth = tls.NoClientCert
+	})
+
+	ginkgo.JustBeforeEach(func() {
+		srv = &testAccountingServer{}
+		server, endpoint = startTestServer(&tls.Config{
+			Certificates: []tls.Certificate{authority.issue(testServerName, x509.ExtKeyUsageServerAuth)},
+			ClientCAs:    authority.pool(),
+			ClientAuth:   clientAuth,
+			MinVersion:   tls.VersionTLS12,
+		}, srv)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Stop()
+
+		for _, key := range []string{constants.CfgTLSCA, constants.CfgTLSCert, constants.CfgTLSKey,
+			constants.CfgTLSServerName} {
+			viper.Set(key, "")
+		}
+
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	ginkgo.Describe("connect to server with TLS", func() {
+		ginkgo.Context("when TLS is not configured", func() {
+			ginkgo.It("should not send data", func() {
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.HaveOccurred())
+				gomega.Expect(srv.identifiers).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when CA bundle and server name are set", func() {
+			ginkgo.It("should send data", func() {
+				viper.Set(constants.CfgTLSCA, caPath)
+				viper.Set(constants.CfgTLSServerName, testServerName)
+
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.Succeed())
+				gomega.Expect(srv.identifiers).To(gomega.Equal([]string{"goat"}))
+			})
+		})
+
+		ginkgo.Context("when server name does not match server certificate", func() {
+			ginkgo.It("should not send data", func() {
+				viper.Set(constants.CfgTLSCA, caPath)
+
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.HaveOccurred())
+				gomega.Expect(srv.identifiers).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when CA bundle does not contain server certificate issuer", func() {
+			ginkgo.It("should not send data", func() {
+				otherCAPath := filepath.Join(dir, "other-ca.crt")
+				writePEM(otherCAPath, "CERTIFICATE", createTestAuthority().cert.Raw)
+
+				viper.Set(constants.CfgTLSCA, otherCAPath)
+				viper.Set(constants.CfgTLSServerName, testServerName)
+
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.HaveOccurred())
+				gomega.Expect(srv.identifiers).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("connect to server with mutual TLS", func() {
+		ginkgo.BeforeEach(func() {
+			clientAuth = tls.RequireAndVerifyClientCert
+		})
+
+		ginkgo.Context("when client certificate and key are set", func() {
+			ginkgo.It("should send data", func() {
+				viper.Set(constants.CfgTLSCA, caPath)
+				viper.Set(constants.CfgTLSServerName, testServerName)
+				viper.Set(constants.CfgTLSCert, certPath)
+				viper.Set(constants.CfgTLSKey, keyPath)
+
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.Succeed())
+				gomega.Expect(srv.identifiers).To(gomega.Equal([]string{"goat"}))
+			})
+		})
+
+		ginkgo.Context("when client certificate is not set", func() {
+			ginkgo.It("should not send data", func() {
+				viper.Set(constants.CfgTLSCA, caPath)
+				viper.Set(constants.CfgTLSServerName, testServerName)
+
+				gomega.Expect(sendTestIdentifier(endpoint)).To(gomega.HaveOccurred())
+				gomega.Expect(srv.identifiers).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("load credentials", func() {
+		ginkgo.Context("when client key is missing", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgTLSCert, certPath)
+
+				_, err := Credentials()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when CA bundle does not exist", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgTLSCA, filepath.Join(dir, "missing.crt"))
+
+				_, err := Credentials()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when CA bundle contains no certificate", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgTLSCA, keyPath)
+
+				_, err := Credentials()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+})
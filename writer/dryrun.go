@@ -0,0 +1,187 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/spf13/viper"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DryRunWriter structure to print records to stdout instead of writing them to Goat server.
+type DryRunWriter struct {
+	name   string
+	format string
+
+	mu      sync.Mutex
+	count   int
+	columns []string
+	rows    bytes.Buffer
+	table   *tabwriter.Writer
+}
+
+// dryRunOutput is the output shared by all dry run writers, the writes are serialized, so records
+// of different resources are not interleaved.
+var (
+	dryRunOutput   io.Writer = os.Stdout
+	dryRunOutputMu sync.Mutex
+)
+
+// CreateDryRunWriter creates writer printing records of a resource with a given name. The format of the records
+// is set by configuration.
+func CreateDryRunWriter(name string) (*DryRunWriter, error) {
+	format := viper.GetString(constants.CfgDryRunFormat)
+	if format == "" {
+		format = constants.FormatJSON
+	}
+
+	if format != constants.FormatJSON && format != constants.FormatTable {
+		return nil, fmt.Errorf("unknown dry run format %s", format)
+	}
+
+	return &DryRunWriter{
+		name:   name,
+		format: format,
+	}, nil
+}
+
+// Write prints record as JSON object on a single line or adds it to the table printed by Finish.
+func (dw *DryRunWriter) Write(rec Record) error {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	if dw.format == constants.FormatTable {
+		if err := dw.addRow(rec); err != nil {
+			return err
+		}
+
+		dw.count++
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{OrigName: true}).Marshal(&buf, rec); err != nil {
+		return err
+	}
+
+	buf.WriteByte('\n')
+	if err := printDryRun(buf.Bytes()); err != nil {
+		return err
+	}
+
+	dw.count++
+	return nil
+}
+
+// SendIdentifier does nothing, there is no Goat server the identifier is sent to.
+func (dw *DryRunWriter) SendIdentifier() error {
+	return nil
+}
+
+// Finish prints the table of records and a summary with the number of printed records. The summary
+// is a JSON object for JSON format, so the output stays valid JSON Lines.
+func (dw *DryRunWriter) Finish() {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	if dw.format == constants.FormatJSON {
+		summary, err := json.Marshal(map[string]interface{}{
+			"summary": map[string]interface{}{"resource": dw.name, "records": dw.count},
+		})
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "resource": dw.name}).Error("error print dry run summary")
+			return
+		}
+
+		dw.print(append(summary, '\n'))
+		return
+	}
+
+	if dw.table != nil {
+		if err := dw.table.Flush(); err != nil {
+			log.WithFields(log.Fields{"error": err, "resource": dw.name}).Error("error print dry run table")
+		}
+	}
+
+	fmt.Fprintf(&dw.rows, "%d %s records\n", dw.count, dw.name)
+	dw.print(dw.rows.Bytes())
+}
+
+// addRow adds record to the table, columns of the table are fields of the first record.
+func (dw *DryRunWriter) addRow(rec Record) error {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{OrigName: true, EmitDefaults: true}).Marshal(&buf, rec); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		return err
+	}
+
+	if dw.table == nil {
+		for column := range fields {
+			dw.columns = append(dw.columns, column)
+		}
+
+		sort.Strings(dw.columns)
+
+		dw.table = tabwriter.NewWriter(&dw.rows, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(dw.table, strings.ToUpper(strings.Join(dw.columns, "\t")))
+	}
+
+	row := make([]string, len(dw.columns))
+	for i, column := range dw.columns {
+		row[i] = formatCell(fields[column])
+	}
+
+	_, err := fmt.Fprintln(dw.table, strings.Join(row, "\t"))
+	return err
+}
+
+func (dw *DryRunWriter) print(p []byte) {
+	if err := printDryRun(p); err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": dw.name}).Error("error print dry run records")
+	}
+}
+
+// printDryRun prints to the output shared by all dry run writers.
+func printDryRun(p []byte) error {
+	dryRunOutputMu.Lock()
+	defer dryRunOutputMu.Unlock()
+
+	_, err := dryRunOutput.Write(p)
+	return err
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+
+		return v
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,92 @@
+package writer
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	pb "github.com/goat-project/goat-proto-go"
+)
+
+var _ = ginkgo.Describe("Dry run tests", func() {
+	var output bytes.Buffer
+
+	ginkgo.BeforeEach(func() {
+		output.Reset()
+		dryRunOutput = &output
+	})
+
+	ginkgo.AfterEach(func() {
+		dryRunOutput = os.Stdout
+		viper.Set(constants.CfgDryRunFormat, "")
+	})
+
+	ginkgo.Describe("create dry run writer", func() {
+		ginkgo.Context("when format is unknown", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgDryRunFormat, "xml")
+
+				_, err := CreateDryRunWriter("network")
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("print records", func() {
+		ginkgo.Context("when format is json", func() {
+			ginkgo.It("should print a record per line and a summary", func() {
+				dw, err := CreateDryRunWriter("network")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(dw.SendIdentifier()).To(gomega.Succeed())
+				gomega.Expect(dw.Write(&pb.IpRecord{SiteName: "site", IpCount: 2})).To(gomega.Succeed())
+				gomega.Expect(dw.Write(&pb.IpRecord{SiteName: "site", IpCount: 3})).To(gomega.Succeed())
+				dw.Finish()
+
+				lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+				gomega.Expect(lines).To(gomega.HaveLen(3))
+				gomega.Expect(lines[0]).To(gomega.MatchJSON(`{"site_name": "site", "ip_count": 2}`))
+				gomega.Expect(lines[1]).To(gomega.MatchJSON(`{"site_name": "site", "ip_count": 3}`))
+				gomega.Expect(lines[2]).To(gomega.MatchJSON(`{"summary": {"resource": "network", "records": 2}}`))
+			})
+		})
+
+		ginkgo.Context("when format is table", func() {
+			ginkgo.It("should print a row per record and a summary", func() {
+				viper.Set(constants.CfgDryRunFormat, constants.FormatTable)
+
+				dw, err := CreateDryRunWriter("network")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(dw.Write(&pb.IpRecord{SiteName: "site", IpType: "IPv4", IpCount: 2})).To(gomega.Succeed())
+				gomega.Expect(output.String()).To(gomega.BeEmpty())
+
+				dw.Finish()
+
+				lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+				gomega.Expect(lines).To(gomega.HaveLen(3))
+				gomega.Expect(strings.Fields(lines[0])).To(gomega.ContainElement("IP_COUNT"))
+				gomega.Expect(strings.Fields(lines[1])).To(gomega.ContainElement("IPv4"))
+				gomega.Expect(lines[2]).To(gomega.Equal("1 network records"))
+			})
+		})
+
+		ginkgo.Context("when there are no records", func() {
+			ginkgo.It("should print only the summary", func() {
+				viper.Set(constants.CfgDryRunFormat, constants.FormatTable)
+
+				dw, err := CreateDryRunWriter("storage")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				dw.Finish()
+
+				gomega.Expect(output.String()).To(gomega.Equal("0 storage records\n"))
+			})
+		})
+	})
+})
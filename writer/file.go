@@ -0,0 +1,175 @@
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/viper"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FileWriter structure to write data to a local file instead of Goat server.
+type FileWriter struct {
+	wrapperI wrapperI
+	name     string
+	zone     string
+	dir      string
+	format   string
+
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+}
+
+type wrapperI interface {
+	WrapRecord(Record) Record
+	WrapIdentifier() Record
+}
+
+const filePermissions = 0600
+
+// CreateFileWriter creates writer with wrapper interface writing to a file with a given name prefix for a zone
+// given by name, which is empty when no zone is configured. The directory and the format of the file are set
+// by configuration.
+func CreateFileWriter(w wrapperI, name, zone string) (*FileWriter, error) {
+	format := viper.GetString(constants.CfgOutputFormat)
+	if format == "" {
+		format = constants.FormatProtobuf
+	}
+
+	if format != constants.FormatProtobuf && format != constants.FormatJSON {
+		return nil, fmt.Errorf("unknown output format %s", format)
+	}
+
+	dir := viper.GetString(constants.CfgOutputDir)
+	if dir == "" {
+		return nil, fmt.Errorf("no output directory in configuration")
+	}
+
+	return &FileWriter{
+		wrapperI: w,
+		name:     name,
+		zone:     zone,
+		dir:      dir,
+		format:   format,
+	}, nil
+}
+
+// Write writes record to the file.
+func (fw *FileWriter) Write(rec Record) error {
+	return fw.write(fw.wrapperI.WrapRecord(rec))
+}
+
+// SendIdentifier creates the file and writes identifier to it.
+func (fw *FileWriter) SendIdentifier() error {
+	if err := fw.open(); err != nil {
+		return err
+	}
+
+	return fw.write(fw.wrapperI.WrapIdentifier())
+}
+
+// Finish flushes buffered records and closes the file.
+func (fw *FileWriter) Finish() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.file == nil {
+		return
+	}
+
+	if err := fw.buf.Flush(); err != nil {
+		log.WithFields(log.Fields{"error": err, "file": fw.file.Name()}).Fatal("error flush records to file")
+	}
+
+	if err := fw.file.Close(); err != nil {
+		log.WithFields(log.Fields{"error": err, "file": fw.file.Name()}).Error("error close file")
+	}
+
+	log.WithFields(log.Fields{"file": fw.file.Name()}).Debug("records written to file")
+}
+
+func (fw *FileWriter) open() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if err := os.MkdirAll(fw.dir, 0700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(fw.dir, fmt.Sprintf("%s.%s", fw.prefix(), fw.extension()))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePermissions)
+	if err != nil {
+		return err
+	}
+
+	fw.file = f
+	fw.buf = bufio.NewWriter(f)
+
+	return nil
+}
+
+func (fw *FileWriter) write(rec Record) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.buf == nil {
+		return fmt.Errorf("file for %s records is not open", fw.name)
+	}
+
+	if fw.format == constants.FormatJSON {
+		return writeJSONLine(fw.buf, rec)
+	}
+
+	return writeDelimited(fw.buf, rec)
+}
+
+// prefix returns name of the file without extension. It contains the zone, so zones accounted with the same
+// identifier are written to different files, and time with nanoseconds, so runs do not overwrite each other.
+func (fw *FileWriter) prefix() string {
+	parts := []string{fw.name, viper.GetString(constants.CfgIdentifier)}
+	if fw.zone != "" {
+		parts = append(parts, fw.zone)
+	}
+
+	return strings.Join(append(parts, time.Now().UTC().Format("20060102T150405.000000000Z")), "-")
+}
+
+func (fw *FileWriter) extension() string {
+	if fw.format == constants.FormatJSON {
+		return "jsonl"
+	}
+
+	return "pb"
+}
+
+// writeDelimited writes record as protobuf message prefixed by its varint encoded length.
+func writeDelimited(w *bufio.Writer, rec Record) error {
+	b := proto.NewBuffer(nil)
+	if err := b.EncodeMessage(rec); err != nil {
+		return err
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeJSONLine writes record as JSON object on a single line.
+func writeJSONLine(w *bufio.Writer, rec Record) error {
+	m := jsonpb.Marshaler{}
+	if err := m.Marshal(w, rec); err != nil {
+		return err
+	}
+
+	return w.WriteByte('\n')
+}
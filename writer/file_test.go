@@ -0,0 +1,81 @@
+package writer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+type testWrapper struct{}
+
+func (tw *testWrapper) WrapRecord(rec Record) Record {
+	return rec
+}
+
+func (tw *testWrapper) WrapIdentifier() Record {
+	return &wrappers.StringValue{Value: viper.GetString(constants.CfgIdentifier)}
+}
+
+var _ = ginkgo.Describe("File writer tests", func() {
+	var (
+		dir string
+		err error
+	)
+
+	ginkgo.BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "goat-one-output")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		viper.Set(constants.CfgOutputDir, dir)
+		viper.Set(constants.CfgIdentifier, "goat-vm")
+	})
+
+	ginkgo.AfterEach(func() {
+		viper.Set(constants.CfgOutputDir, nil)
+		viper.Set(constants.CfgIdentifier, nil)
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	write := func(zone string) {
+		fw, err := CreateFileWriter(&testWrapper{}, "vm", zone)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(fw.SendIdentifier()).To(gomega.Succeed())
+		gomega.Expect(fw.Write(&wrappers.StringValue{Value: zone})).To(gomega.Succeed())
+		fw.Finish()
+	}
+
+	ginkgo.Describe("write records of zones", func() {
+		ginkgo.Context("when two zones are accounted with the same identifier at once", func() {
+			ginkgo.It("should write every zone to its own file", func() {
+				write("zone-a")
+				write("zone-b")
+
+				a, err := filepath.Glob(filepath.Join(dir, "vm-goat-vm-zone-a-*.pb"))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(a).To(gomega.HaveLen(1))
+
+				b, err := filepath.Glob(filepath.Join(dir, "vm-goat-vm-zone-b-*.pb"))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(b).To(gomega.HaveLen(1))
+			})
+		})
+
+		ginkgo.Context("when no zone is configured and runs follow each other", func() {
+			ginkgo.It("should write every run to its own file", func() {
+				write("")
+				write("")
+
+				files, err := filepath.Glob(filepath.Join(dir, "vm-goat-vm-*.pb"))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(files).To(gomega.HaveLen(2))
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ package writer
 type Interface interface {
 	Write(Record) error
 	SendIdentifier() error
+	Finish()
 }
 
 // Record represents data for writing.
@@ -0,0 +1,432 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Spool structure to store data which were not delivered to Goat server in a local directory.
+// Every spool entry is a file with length-delimited data in the order they were sent.
+type Spool struct {
+	dir string
+}
+
+// SpoolWriter structure to write data to Goat server. All the written data are stored in a spool entry
+// until Goat server confirms them, so no data are lost when the server is unreachable.
+type SpoolWriter struct {
+	writerI    spoolWriterI
+	grpcConn   *grpc.ClientConn
+	spool      *Spool
+	name       string
+	entry      *spoolEntry
+	delivering bool
+	mu         sync.Mutex
+}
+
+type spoolWriterI interface {
+	writerI
+	wrapperI
+}
+
+type replayerI interface {
+	SetUp(*grpc.ClientConn) error
+	Send(Record) error
+	CreateData() Record
+	Close() (*empty.Empty, error)
+}
+
+type spoolEntry struct {
+	path string
+	file *os.File
+	buf  *bufio.Writer
+}
+
+const (
+	spoolExtension   = ".spool"
+	pendingExtension = ".pending"
+	newExtension     = ".new"
+)
+
+// CreateSpool creates spool in a given directory.
+func CreateSpool(dir string) (*Spool, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no spool directory in configuration")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Spool{
+		dir: dir,
+	}, nil
+}
+
+// Entries returns spool entries for a given resource name in the order they were created.
+func (s *Spool) Entries(name string) ([]string, error) {
+	entries, err := filepath.Glob(filepath.Join(s.dir, name+"-*"+spoolExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(entries)
+
+	return entries, nil
+}
+
+// Replay sends data from a spool entry to Goat server and removes the entry once the server confirms them.
+func (s *Spool) Replay(entry string, w replayerI, conn *grpc.ClientConn) error {
+	f, err := os.Open(entry)
+	if err != nil {
+		return err
+	}
+
+	if err = w.SetUp(conn); err != nil {
+		closeFile(f)
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		data := w.CreateData()
+
+		err = readDelimited(r, data)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			closeFile(f)
+			return err
+		}
+
+		if err = w.Send(data); err != nil {
+			closeFile(f)
+			return err
+		}
+	}
+
+	closeFile(f)
+
+	if _, err = w.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(entry)
+}
+
+// Recover commits pending spool entries of a given resource which were left by a process that ended
+// without finishing them, e.g. by a crash. A pending entry is locked by its process while it is open,
+// so entries of running processes are skipped. An incomplete record at the end of the entry is cut off.
+// Recovered entries can contain records which were already delivered, Goat server has to deal with duplicates.
+func (s *Spool) Recover(name string) error {
+	pending, err := filepath.Glob(filepath.Join(s.dir, name+"-*"+pendingExtension))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range pending {
+		recovered, err := recoverEntry(path)
+		if err != nil {
+			return err
+		}
+
+		if recovered {
+			log.WithFields(log.Fields{"entry": path, "resource": name}).Warn("orphaned spool entry recovered")
+		}
+	}
+
+	return nil
+}
+
+// recoverEntry commits pending entry if it is not locked by other process.
+func recoverEntry(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, filePermissions)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	defer closeFile(f)
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == syscall.EWOULDBLOCK {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	size, err := completeSize(f)
+	if err != nil {
+		return false, err
+	}
+
+	if err = f.Truncate(size); err != nil {
+		return false, err
+	}
+
+	if err = f.Sync(); err != nil {
+		return false, err
+	}
+
+	err = os.Rename(path, strings.TrimSuffix(path, pendingExtension)+spoolExtension)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// completeSize returns size of the complete length-delimited records at the beginning of a file.
+func completeSize(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+
+	var size int64
+	for {
+		length, err := binary.ReadUvarint(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := io.CopyN(ioutil.Discard, r, int64(length))
+		if err == io.EOF {
+			return size, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		size += int64(proto.SizeVarint(length)) + n
+	}
+}
+
+// begin creates pending entry locked for the whole life of the entry. The entry is created under a temporary
+// name and renamed when it is locked, so Recover never sees an unlocked entry of a running process.
+func (s *Spool) begin(name string) (*spoolEntry, error) {
+	base := filepath.Join(s.dir, fmt.Sprintf("%s-%s", name, time.Now().UTC().Format("20060102T150405.000000000Z")))
+
+	f, err := os.OpenFile(base+newExtension, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		closeFile(f)
+		return nil, err
+	}
+
+	if err = os.Rename(base+newExtension, base+pendingExtension); err != nil {
+		closeFile(f)
+		return nil, err
+	}
+
+	return &spoolEntry{
+		path: base + pendingExtension,
+		file: f,
+		buf:  bufio.NewWriter(f),
+	}, nil
+}
+
+// append stores record to the entry. The record is synced to disk, so it is not lost when the process crashes.
+func (e *spoolEntry) append(rec Record) error {
+	if err := writeDelimited(e.buf, rec); err != nil {
+		return err
+	}
+
+	if err := e.buf.Flush(); err != nil {
+		return err
+	}
+
+	return e.file.Sync()
+}
+
+// commit makes the entry visible for replaying.
+func (e *spoolEntry) commit() (string, error) {
+	if err := e.buf.Flush(); err != nil {
+		return "", err
+	}
+
+	if err := e.file.Sync(); err != nil {
+		return "", err
+	}
+
+	path := strings.TrimSuffix(e.path, pendingExtension) + spoolExtension
+
+	// the entry is renamed before it is closed, so it is never unlocked as pending
+	if err := os.Rename(e.path, path); err != nil {
+		return "", err
+	}
+
+	return path, e.file.Close()
+}
+
+// discard removes the entry when all its data were delivered.
+func (e *spoolEntry) discard() error {
+	if err := os.Remove(e.path); err != nil {
+		return err
+	}
+
+	return e.file.Close()
+}
+
+// CreateSpoolWriter creates writer with writer interface, gRPC connection and spool.
+// The connection can be nil when Goat server is unreachable, all the data are spooled in that case.
+func CreateSpoolWriter(w spoolWriterI, conn *grpc.ClientConn, spool *Spool, name string) *SpoolWriter {
+	sw := &SpoolWriter{
+		writerI:  w,
+		grpcConn: conn,
+		spool:    spool,
+		name:     name,
+	}
+
+	if conn == nil {
+		log.WithFields(log.Fields{"resource": name}).Warn("no connection to Goat server, records will be spooled")
+		return sw
+	}
+
+	if err := w.SetUp(conn); err != nil {
+		log.WithFields(log.Fields{"error": err, "resource": name}).Warn("error create gRPC client stream, " +
+			"records will be spooled")
+		return sw
+	}
+
+	sw.delivering = true
+
+	return sw
+}
+
+// Write stores record to spool entry and writes it to Goat server.
+func (sw *SpoolWriter) Write(rec Record) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.entry == nil {
+		return fmt.Errorf("spool entry for %s records is not open", sw.name)
+	}
+
+	if err := sw.entry.append(sw.writerI.WrapRecord(rec)); err != nil {
+		return err
+	}
+
+	if sw.delivering {
+		if err := sw.writerI.Write(rec); err != nil {
+			sw.stopDelivering(err)
+		}
+	}
+
+	return nil
+}
+
+// SendIdentifier opens spool entry, stores identifier to it and sends the identifier to Goat server.
+func (sw *SpoolWriter) SendIdentifier() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	entry, err := sw.spool.begin(sw.name)
+	if err != nil {
+		return err
+	}
+
+	sw.entry = entry
+
+	if err = sw.entry.append(sw.writerI.WrapIdentifier()); err != nil {
+		return err
+	}
+
+	if sw.delivering {
+		if err = sw.writerI.SendIdentifier(); err != nil {
+			sw.stopDelivering(err)
+		}
+	}
+
+	return nil
+}
+
+// Finish gets to know to the Goat server that a writing is finished and a response is expected.
+// The spool entry is removed when Goat server confirms the data, it is kept for flushing otherwise.
+func (sw *SpoolWriter) Finish() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.delivering {
+		if _, err := sw.writerI.Close(); err != nil {
+			sw.stopDelivering(err)
+		}
+	}
+
+	if sw.grpcConn != nil {
+		if err := sw.grpcConn.Close(); err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("error close gRPC connection")
+		}
+	}
+
+	if sw.entry == nil {
+		return
+	}
+
+	if sw.delivering {
+		if err := sw.entry.discard(); err != nil {
+			log.WithFields(log.Fields{"error": err, "entry": sw.entry.path}).Error("error remove spool entry")
+		}
+
+		return
+	}
+
+	path, err := sw.entry.commit()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "entry": sw.entry.path}).Fatal("error store spool entry")
+	}
+
+	log.WithFields(log.Fields{"entry": path, "resource": sw.name}).Warn("records were not delivered to Goat " +
+		"server, they are spooled until flush")
+}
+
+func (sw *SpoolWriter) stopDelivering(err error) {
+	log.WithFields(log.Fields{"error": err, "resource": sw.name}).Error("error write to Goat server, " +
+		"records will be spooled")
+
+	sw.delivering = false
+}
+
+// readDelimited reads data prefixed by its varint encoded length.
+func readDelimited(r *bufio.Reader, data Record) error {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, length)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(b, data)
+}
+
+func closeFile(f *os.File) {
+	if err := f.Close(); err != nil {
+		log.WithFields(log.Fields{"error": err, "file": f.Name()}).Error("error close file")
+	}
+}
@@ -0,0 +1,223 @@
+package writer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+)
+
+type testReplayer struct {
+	sent     []string
+	sendErr  error
+	closeErr error
+}
+
+func (tr *testReplayer) SetUp(*grpc.ClientConn) error {
+	return nil
+}
+
+func (tr *testReplayer) Send(data Record) error {
+	if tr.sendErr != nil {
+		return tr.sendErr
+	}
+
+	tr.sent = append(tr.sent, data.(*wrappers.StringValue).Value)
+
+	return nil
+}
+
+func (tr *testReplayer) CreateData() Record {
+	return &wrappers.StringValue{}
+}
+
+func (tr *testReplayer) Close() (*empty.Empty, error) {
+	return &empty.Empty{}, tr.closeErr
+}
+
+var _ = ginkgo.Describe("Spool tests", func() {
+	var (
+		dir   string
+		spool *Spool
+		err   error
+	)
+
+	ginkgo.BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "goat-one-spool")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		spool, err = CreateSpool(dir)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	ginkgo.AfterEach(func() {
+		gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+	})
+
+	storeEntry := func(values ...string) string {
+		entry, err := spool.begin("vm")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		for _, value := range values {
+			gomega.Expect(entry.append(&wrappers.StringValue{Value: value})).To(gomega.Succeed())
+		}
+
+		path, err := entry.commit()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return path
+	}
+
+	ginkgo.Describe("create spool", func() {
+		ginkgo.Context("when directory is not set", func() {
+			ginkgo.It("should not create spool", func() {
+				s, err := CreateSpool("")
+
+				gomega.Expect(s).To(gomega.BeNil())
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("list entries", func() {
+		ginkgo.Context("when entries are committed", func() {
+			ginkgo.It("should list them in order they were created", func() {
+				first := storeEntry("a")
+				second := storeEntry("b")
+
+				entries, err := spool.Entries("vm")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entries).To(gomega.Equal([]string{first, second}))
+			})
+		})
+
+		ginkgo.Context("when entry is pending or discarded", func() {
+			ginkgo.It("should not list it", func() {
+				_, err := spool.begin("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				discarded, err := spool.begin("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(discarded.discard()).To(gomega.Succeed())
+
+				entries, err := spool.Entries("vm")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entries).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when entries belong to other resource", func() {
+			ginkgo.It("should not list them", func() {
+				storeEntry("a")
+
+				entries, err := spool.Entries("storage")
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entries).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("replay entry", func() {
+		ginkgo.Context("when server confirms data", func() {
+			ginkgo.It("should send data in order and remove entry", func() {
+				entry := storeEntry("identifier", "first", "second")
+				replayer := &testReplayer{}
+
+				gomega.Expect(spool.Replay(entry, replayer, nil)).To(gomega.Succeed())
+
+				gomega.Expect(replayer.sent).To(gomega.Equal([]string{"identifier", "first", "second"}))
+				gomega.Expect(entry).NotTo(gomega.BeAnExistingFile())
+			})
+		})
+
+		ginkgo.Context("when server does not confirm data", func() {
+			ginkgo.It("should keep entry", func() {
+				entry := storeEntry("identifier", "first")
+				replayer := &testReplayer{closeErr: errors.New("unavailable")}
+
+				gomega.Expect(spool.Replay(entry, replayer, nil)).NotTo(gomega.Succeed())
+
+				gomega.Expect(entry).To(gomega.BeAnExistingFile())
+			})
+		})
+
+		ginkgo.Context("when data cannot be sent", func() {
+			ginkgo.It("should keep entry", func() {
+				entry := storeEntry("identifier")
+				replayer := &testReplayer{sendErr: errors.New("unavailable")}
+
+				gomega.Expect(spool.Replay(entry, replayer, nil)).NotTo(gomega.Succeed())
+
+				gomega.Expect(entry).To(gomega.BeAnExistingFile())
+			})
+		})
+	})
+
+	ginkgo.Describe("recover entries", func() {
+		ginkgo.Context("when process crashed in the middle of an entry", func() {
+			ginkgo.It("should recover complete records of the entry", func() {
+				entry, err := spool.begin("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entry.append(&wrappers.StringValue{Value: "identifier"})).To(gomega.Succeed())
+				gomega.Expect(entry.append(&wrappers.StringValue{Value: "first"})).To(gomega.Succeed())
+
+				// crash while the next record is being written
+				_, err = entry.file.Write([]byte{10, 1})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entry.file.Close()).To(gomega.Succeed())
+
+				gomega.Expect(spool.Recover("vm")).To(gomega.Succeed())
+
+				entries, err := spool.Entries("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entries).To(gomega.HaveLen(1))
+				gomega.Expect(entry.path).NotTo(gomega.BeAnExistingFile())
+
+				replayer := &testReplayer{}
+				gomega.Expect(spool.Replay(entries[0], replayer, nil)).To(gomega.Succeed())
+				gomega.Expect(replayer.sent).To(gomega.Equal([]string{"identifier", "first"}))
+			})
+		})
+
+		ginkgo.Context("when entry is still written", func() {
+			ginkgo.It("should keep it pending", func() {
+				entry, err := spool.begin("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entry.append(&wrappers.StringValue{Value: "identifier"})).To(gomega.Succeed())
+
+				gomega.Expect(spool.Recover("vm")).To(gomega.Succeed())
+
+				entries, err := spool.Entries("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entries).To(gomega.BeEmpty())
+				gomega.Expect(entry.path).To(gomega.BeAnExistingFile())
+
+				path, err := entry.commit()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(path).To(gomega.BeAnExistingFile())
+			})
+		})
+
+		ginkgo.Context("when record is appended", func() {
+			ginkgo.It("should store it on disk before the entry is committed", func() {
+				entry, err := spool.begin("vm")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(entry.append(&wrappers.StringValue{Value: "identifier"})).To(gomega.Succeed())
+
+				info, err := os.Stat(entry.path)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(info.Size()).To(gomega.BeNumerically(">", 0))
+
+				gomega.Expect(entry.discard()).To(gomega.Succeed())
+			})
+		})
+	})
+})
@@ -0,0 +1,283 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/metrics"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Validator validates records by rules for fields of the records.
+type Validator struct {
+	rules []rule
+}
+
+// ValidatingWriter structure to validate records before writing them. Records which break a rule are written
+// to a reject file with the reasons instead of writing them.
+type ValidatingWriter struct {
+	writer     Interface
+	validator  *Validator
+	name       string
+	rejectFile string
+	mu         sync.Mutex
+}
+
+// RejectedError represents error of a record which was rejected by validation.
+type RejectedError struct {
+	Reasons []string
+}
+
+type rule struct {
+	name   string
+	fields []string
+	check  func(fields []reflect.Value) bool
+	reason string
+}
+
+// rejected represents a line of the reject file.
+type rejected struct {
+	Time     time.Time       `json:"time"`
+	Resource string          `json:"resource"`
+	Reasons  []string        `json:"reasons"`
+	Record   json.RawMessage `json:"record"`
+}
+
+// CreateValidator creates validator with given rules for records like a given record. Rules have a form of
+// required=Field, non-negative=Field or ordered=Field,Field, where Field is a name of a record field.
+func CreateValidator(rules []string, record Record) (*Validator, error) {
+	v := &Validator{}
+
+	recordType := reflect.TypeOf(record).Elem()
+	for _, r := range rules {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("rule %s is not in form name=field", r)
+		}
+
+		fields := strings.Split(parts[1], ",")
+		for _, field := range fields {
+			if _, ok := recordType.FieldByName(field); !ok {
+				return nil, fmt.Errorf("rule %s: %s has no field %s", r, recordType.Name(), field)
+			}
+		}
+
+		parsed, err := parseRule(parts[0], fields)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", r, err)
+		}
+
+		v.rules = append(v.rules, parsed)
+	}
+
+	return v, nil
+}
+
+func parseRule(name string, fields []string) (rule, error) {
+	switch name {
+	case constants.RuleRequired:
+		if len(fields) != 1 {
+			return rule{}, fmt.Errorf("required rule has one field")
+		}
+
+		return rule{name: name, fields: fields, check: func(v []reflect.Value) bool { return !isEmpty(v[0]) },
+			reason: fields[0] + " is required"}, nil
+	case constants.RuleNonNegative:
+		if len(fields) != 1 {
+			return rule{}, fmt.Errorf("non-negative rule has one field")
+		}
+
+		return rule{name: name, fields: fields, check: func(v []reflect.Value) bool { return !isNegative(v[0]) },
+			reason: fields[0] + " is negative"}, nil
+	case constants.RuleOrdered:
+		if len(fields) != 2 {
+			return rule{}, fmt.Errorf("ordered rule has two fields")
+		}
+
+		return rule{name: name, fields: fields, check: func(v []reflect.Value) bool { return !isAfter(v[0], v[1]) },
+			reason: fields[0] + " is after " + fields[1]}, nil
+	default:
+		return rule{}, fmt.Errorf("unknown rule %s", name)
+	}
+}
+
+// Validate returns reasons of all the rules a record breaks.
+func (v *Validator) Validate(rec Record) []string {
+	value := reflect.ValueOf(rec).Elem()
+
+	var reasons []string
+	for _, r := range v.rules {
+		fields := make([]reflect.Value, len(r.fields))
+		for i, field := range r.fields {
+			fields[i] = value.FieldByName(field)
+		}
+
+		if !r.check(fields) {
+			reasons = append(reasons, r.reason)
+		}
+	}
+
+	return reasons
+}
+
+// isEmpty returns true for zero values and for wrappers of zero values, e.g. empty string or nil.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+
+		if wrapped := v.Elem().FieldByName("Value"); wrapped.IsValid() {
+			return isEmpty(wrapped)
+		}
+
+		return false
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	default:
+		return false
+	}
+}
+
+// isNegative returns true for negative numbers, durations and wrappers of negative numbers.
+func isNegative(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+
+		if d, ok := v.Interface().(*duration.Duration); ok {
+			return d.Seconds < 0 || d.Nanos < 0
+		}
+
+		if wrapped := v.Elem().FieldByName("Value"); wrapped.IsValid() {
+			return isNegative(wrapped)
+		}
+
+		return false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() < 0
+	default:
+		return false
+	}
+}
+
+// isAfter returns true when both timestamps are set and the first one is after the second one.
+func isAfter(first, second reflect.Value) bool {
+	f, ok := first.Interface().(*timestamp.Timestamp)
+	if !ok || f == nil {
+		return false
+	}
+
+	s, ok := second.Interface().(*timestamp.Timestamp)
+	if !ok || s == nil {
+		return false
+	}
+
+	return f.Seconds > s.Seconds || (f.Seconds == s.Seconds && f.Nanos > s.Nanos)
+}
+
+// CreateValidatingWriter creates writer validating records of a resource with a given name before writing them
+// by a given writer. Rejected records are written to a given reject file, they are logged when it is not set.
+func CreateValidatingWriter(w Interface, v *Validator, name, rejectFile string) *ValidatingWriter {
+	return &ValidatingWriter{
+		writer:     w,
+		validator:  v,
+		name:       name,
+		rejectFile: rejectFile,
+	}
+}
+
+// Write writes record when it is valid. Invalid record is written to the reject file and RejectedError
+// is returned.
+func (vw *ValidatingWriter) Write(rec Record) error {
+	reasons := vw.validator.Validate(rec)
+	if len(reasons) == 0 {
+		return vw.writer.Write(rec)
+	}
+
+	metrics.Count(vw.name, metrics.StageRejected)
+
+	if err := vw.reject(rec, reasons); err != nil {
+		log.WithFields(log.Fields{"error": err, "reject-file": vw.rejectFile}).Error("error write rejected record")
+	}
+
+	return &RejectedError{Reasons: reasons}
+}
+
+// SendIdentifier sends identifier by the writer.
+func (vw *ValidatingWriter) SendIdentifier() error {
+	return vw.writer.SendIdentifier()
+}
+
+// Finish finishes the writer.
+func (vw *ValidatingWriter) Finish() {
+	vw.writer.Finish()
+}
+
+// reject appends record with the reasons to the reject file as a single line, so the file can be appended
+// by writers of all the resources.
+func (vw *ValidatingWriter) reject(rec Record, reasons []string) error {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, rec); err != nil {
+		return err
+	}
+
+	if vw.rejectFile == "" {
+		log.WithFields(log.Fields{"resource": vw.name, "reasons": reasons, "record": buf.String()}).Warn(
+			"record rejected")
+		return nil
+	}
+
+	line, err := json.Marshal(rejected{
+		Time:     time.Now().UTC(),
+		Resource: vw.name,
+		Reasons:  reasons,
+		Record:   buf.Bytes(),
+	})
+	if err != nil {
+		return err
+	}
+
+	vw.mu.Lock()
+	defer vw.mu.Unlock()
+
+	f, err := os.OpenFile(vw.rejectFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, filePermissions)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		closeFile(f)
+		return err
+	}
+
+	return f.Close()
+}
+
+func (e *RejectedError) Error() string {
+	return "record rejected: " + strings.Join(e.Reasons, ", ")
+}
@@ -0,0 +1,191 @@
+package writer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	pb "github.com/goat-project/goat-proto-go"
+)
+
+type recordingWriter struct {
+	records []Record
+}
+
+func (rw *recordingWriter) Write(rec Record) error {
+	rw.records = append(rw.records, rec)
+	return nil
+}
+
+func (rw *recordingWriter) SendIdentifier() error {
+	return nil
+}
+
+func (rw *recordingWriter) Finish() {}
+
+var _ = ginkgo.Describe("Validator tests", func() {
+	rules := []string{"required=SiteName", "required=GlobalUserName", "non-negative=WallDuration",
+		"ordered=StartTime,EndTime"}
+
+	valid := func() *pb.VmRecord {
+		return &pb.VmRecord{
+			SiteName:       "site",
+			GlobalUserName: &wrappers.StringValue{Value: "user"},
+			StartTime:      &timestamp.Timestamp{Seconds: 1540000000},
+			EndTime:        &timestamp.Timestamp{Seconds: 1540003600},
+			WallDuration:   &duration.Duration{Seconds: 3600},
+		}
+	}
+
+	ginkgo.Describe("create validator", func() {
+		ginkgo.Context("when rules are correct", func() {
+			ginkgo.It("should not return error", func() {
+				_, err := CreateValidator(rules, &pb.VmRecord{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when rule is unknown", func() {
+			ginkgo.It("should return error", func() {
+				_, err := CreateValidator([]string{"unique=SiteName"}, &pb.VmRecord{})
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when record has no such field", func() {
+			ginkgo.It("should return error", func() {
+				_, err := CreateValidator([]string{"required=MachineName"}, &pb.IpRecord{})
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when rule has wrong number of fields", func() {
+			ginkgo.It("should return error", func() {
+				_, err := CreateValidator([]string{"ordered=StartTime"}, &pb.VmRecord{})
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("validate record", func() {
+		var v *Validator
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			v, err = CreateValidator(rules, &pb.VmRecord{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.Context("when record is valid", func() {
+			ginkgo.It("should return no reasons", func() {
+				gomega.Expect(v.Validate(valid())).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.Context("when required fields are missing", func() {
+			ginkgo.It("should return reason for every field", func() {
+				rec := valid()
+				rec.SiteName = ""
+				rec.GlobalUserName = &wrappers.StringValue{}
+
+				gomega.Expect(v.Validate(rec)).To(gomega.Equal([]string{"SiteName is required",
+					"GlobalUserName is required"}))
+			})
+		})
+
+		ginkgo.Context("when duration is negative", func() {
+			ginkgo.It("should return reason", func() {
+				rec := valid()
+				rec.WallDuration = &duration.Duration{Seconds: -3600}
+
+				gomega.Expect(v.Validate(rec)).To(gomega.Equal([]string{"WallDuration is negative"}))
+			})
+		})
+
+		ginkgo.Context("when end is before start", func() {
+			ginkgo.It("should return reason", func() {
+				rec := valid()
+				rec.EndTime = &timestamp.Timestamp{Seconds: 1530000000}
+
+				gomega.Expect(v.Validate(rec)).To(gomega.Equal([]string{"StartTime is after EndTime"}))
+			})
+		})
+
+		ginkgo.Context("when end is not set", func() {
+			ginkgo.It("should return no reasons", func() {
+				rec := valid()
+				rec.EndTime = nil
+
+				gomega.Expect(v.Validate(rec)).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Describe("write record", func() {
+		var (
+			dir  string
+			rw   *recordingWriter
+			vw   *ValidatingWriter
+			path string
+		)
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "goat-one-reject")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			v, err := CreateValidator(rules, &pb.VmRecord{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			path = filepath.Join(dir, "rejected.jsonl")
+			rw = &recordingWriter{}
+			vw = CreateValidatingWriter(rw, v, "vm", path)
+		})
+
+		ginkgo.AfterEach(func() {
+			gomega.Expect(os.RemoveAll(dir)).To(gomega.Succeed())
+		})
+
+		ginkgo.Context("when record is valid", func() {
+			ginkgo.It("should write it by the writer", func() {
+				gomega.Expect(vw.Write(valid())).To(gomega.Succeed())
+				gomega.Expect(rw.records).To(gomega.HaveLen(1))
+
+				_, err := os.Stat(path)
+				gomega.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.Context("when record is invalid", func() {
+			ginkgo.It("should append it to the reject file with the reasons", func() {
+				rec := valid()
+				rec.SiteName = ""
+
+				err := vw.Write(rec)
+				gomega.Expect(err).To(gomega.BeAssignableToTypeOf(&RejectedError{}))
+				gomega.Expect(vw.Write(rec)).To(gomega.HaveOccurred())
+				gomega.Expect(rw.records).To(gomega.BeEmpty())
+
+				content, err := ioutil.ReadFile(path)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+				gomega.Expect(lines).To(gomega.HaveLen(2))
+
+				var line rejected
+				gomega.Expect(json.Unmarshal([]byte(lines[0]), &line)).To(gomega.Succeed())
+				gomega.Expect(line.Resource).To(gomega.Equal("vm"))
+				gomega.Expect(line.Reasons).To(gomega.Equal([]string{"SiteName is required"}))
+				gomega.Expect(string(line.Record)).To(gomega.ContainSubstring(`"globalUserName":"user"`))
+			})
+		})
+	})
+})
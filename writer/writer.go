@@ -13,7 +13,7 @@ type Writer struct {
 }
 
 type writerI interface {
-	SetUp(*grpc.ClientConn)
+	SetUp(*grpc.ClientConn) error
 	Write(Record) error
 	SendIdentifier() error
 	Close() (*empty.Empty, error)
@@ -21,7 +21,9 @@ type writerI interface {
 
 // CreateWriter creates writer with writer interface and gRPC connection.
 func CreateWriter(w writerI, conn *grpc.ClientConn) *Writer {
-	w.SetUp(conn)
+	if err := w.SetUp(conn); err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("error create gRPC client stream")
+	}
 
 	return &Writer{
 		writerI:  w,
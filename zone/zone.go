@@ -0,0 +1,165 @@
+package zone
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/goat-project/goat-one/secret"
+	"github.com/spf13/viper"
+)
+
+// Zone represents an OpenNebula source, e.g. a zone of a federation, accounted with its own endpoint, secret,
+// timeout, site name and cloud type.
+type Zone struct {
+	Name                 string `mapstructure:"name"`
+	OpennebulaEndpoint   string `mapstructure:"opennebula-endpoint"`
+	OpennebulaSecret     string `mapstructure:"opennebula-secret"`
+	OpennebulaSecretFile string `mapstructure:"opennebula-secret-file"`
+	OpennebulaTimeout    string `mapstructure:"opennebula-timeout"`
+	SiteName             string `mapstructure:"site-name"`
+	CloudType            string `mapstructure:"cloud-type"`
+
+	settings map[string]string
+}
+
+// mu serializes accounting of zones, since settings of a zone are set to the configuration read by accounting.
+var mu sync.Mutex
+
+// current is the name of the zone being accounted.
+var current struct {
+	sync.RWMutex
+	name string
+}
+
+// List returns zones from configuration. Settings which are not set for a zone are taken from the top level
+// of configuration. When no zone is configured, it returns a single zone without name with the top level settings.
+func List() ([]*Zone, error) {
+	var zones []*Zone
+	if err := viper.UnmarshalKey(constants.CfgZones, &zones); err != nil {
+		return nil, err
+	}
+
+	if len(zones) == 0 {
+		zones = []*Zone{{}}
+
+		return zones, zones[0].resolve()
+	}
+
+	names := map[string]bool{}
+	for _, z := range zones {
+		if z.Name == "" {
+			return nil, fmt.Errorf("zone without name")
+		}
+
+		if names[z.Name] {
+			return nil, fmt.Errorf("zone %s is configured more than once", z.Name)
+		}
+		names[z.Name] = true
+
+		if err := z.resolve(); err != nil {
+			return nil, err
+		}
+	}
+
+	return zones, nil
+}
+
+// Configured returns true when zones are configured, so settings set by zones are not required at the top level
+// of configuration.
+func Configured() bool {
+	var zones []*Zone
+	return viper.UnmarshalKey(constants.CfgZones, &zones) == nil && len(zones) > 0
+}
+
+// Sets returns true when a configuration key is set by zones.
+func Sets(key string) bool {
+	_, ok := (&Zone{}).values()[key]
+	return ok
+}
+
+// Check returns error when a setting of the zone given by a configuration key is not set.
+func (z *Zone) Check(keys []string) error {
+	for _, key := range keys {
+		if value, ok := z.settings[key]; ok && value == "" {
+			return fmt.Errorf("zone %s: %s not set", z.Name, key)
+		}
+	}
+
+	return nil
+}
+
+// Run sets settings of the zone to the configuration and runs a given function. Configured zones are run one
+// at a time, the zone with the top level settings runs the function as it is.
+func (z *Zone) Run(f func()) {
+	if z.Name == "" {
+		f()
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for key, value := range z.settings {
+		viper.Set(key, value)
+	}
+
+	setCurrent(z.Name)
+	defer setCurrent("")
+
+	f()
+}
+
+// Current returns name of the zone being accounted or empty string when no configured zone is accounted.
+func Current() string {
+	current.RLock()
+	defer current.RUnlock()
+
+	return current.name
+}
+
+func setCurrent(name string) {
+	current.Lock()
+	defer current.Unlock()
+
+	current.name = name
+}
+
+// values maps configuration keys to the settings of the zone. Site name and cloud type are set for all
+// the resources.
+func (z *Zone) values() map[string]string {
+	return map[string]string{
+		constants.CfgOpennebulaEndpoint: z.OpennebulaEndpoint,
+		constants.CfgOpennebulaSecret:   z.OpennebulaSecret,
+		constants.CfgOpennebulaTimeout:  z.OpennebulaTimeout,
+		constants.CfgSiteName:           z.SiteName,
+		constants.CfgNetworkSiteName:    z.SiteName,
+		constants.CfgSite:               z.SiteName,
+		constants.CfgCloudType:          z.CloudType,
+		constants.CfgNetworkCloudType:   z.CloudType,
+	}
+}
+
+// resolve reads secret of the zone from its secret file and takes settings which are not set for the zone
+// from the top level of configuration. The secret is registered, so it is redacted in logs.
+func (z *Zone) resolve() error {
+	if z.OpennebulaSecret == "" && z.OpennebulaSecretFile != "" {
+		s, err := secret.ReadFile(z.OpennebulaSecretFile)
+		if err != nil {
+			return fmt.Errorf("zone %s: %v", z.Name, err)
+		}
+
+		z.OpennebulaSecret = s
+	}
+
+	z.settings = z.values()
+	for key, value := range z.settings {
+		if value == "" {
+			z.settings[key] = viper.GetString(key)
+		}
+	}
+
+	secret.Register(z.settings[constants.CfgOpennebulaSecret])
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package zone
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/goat-project/goat-one/constants"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = ginkgo.Describe("Zone tests", func() {
+	ginkgo.BeforeEach(func() {
+		viper.Reset()
+		viper.Set(constants.CfgOpennebulaTimeout, "1m")
+		viper.Set(constants.CfgNetworkSiteName, "network-site")
+	})
+
+	ginkgo.AfterEach(func() {
+		viper.Reset()
+	})
+
+	ginkgo.Describe("list zones", func() {
+		ginkgo.Context("when no zone is configured", func() {
+			ginkgo.It("should return a zone with the top level settings", func() {
+				viper.Set(constants.CfgOpennebulaEndpoint, "http://one:2633/RPC2")
+
+				zones, err := List()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(zones).To(gomega.HaveLen(1))
+				gomega.Expect(zones[0].Name).To(gomega.BeEmpty())
+				gomega.Expect(zones[0].settings[constants.CfgOpennebulaEndpoint]).To(gomega.Equal(
+					"http://one:2633/RPC2"))
+			})
+		})
+
+		ginkgo.Context("when zones are configured", func() {
+			ginkgo.It("should take settings which are not set for a zone from the top level", func() {
+				viper.Set(constants.CfgZones, []map[string]interface{}{
+					{"name": "zone-a", "opennebula-endpoint": "http://a:2633/RPC2", "site-name": "site-a"},
+					{"name": "zone-b", "opennebula-endpoint": "http://b:2633/RPC2", "opennebula-timeout": "2m"},
+				})
+
+				zones, err := List()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(zones).To(gomega.HaveLen(2))
+
+				gomega.Expect(zones[0].settings[constants.CfgNetworkSiteName]).To(gomega.Equal("site-a"))
+				gomega.Expect(zones[0].settings[constants.CfgSite]).To(gomega.Equal("site-a"))
+				gomega.Expect(zones[0].settings[constants.CfgOpennebulaTimeout]).To(gomega.Equal("1m"))
+				gomega.Expect(zones[1].settings[constants.CfgNetworkSiteName]).To(gomega.Equal("network-site"))
+				gomega.Expect(zones[1].settings[constants.CfgOpennebulaTimeout]).To(gomega.Equal("2m"))
+			})
+		})
+
+		ginkgo.Context("when zone has secret file", func() {
+			ginkgo.It("should read the secret from the file", func() {
+				f, err := ioutil.TempFile("", "goat-one-one_auth")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				defer os.Remove(f.Name()) // nolint: errcheck
+
+				_, err = f.WriteString("oneadmin:zone-a\n")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(f.Close()).To(gomega.Succeed())
+
+				viper.Set(constants.CfgZones, []map[string]interface{}{
+					{"name": "zone-a", "opennebula-secret-file": f.Name()},
+					{"name": "zone-b", "opennebula-secret-file": f.Name() + "-missing"},
+				})
+
+				_, err = List()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+
+				viper.Set(constants.CfgZones, []map[string]interface{}{
+					{"name": "zone-a", "opennebula-secret-file": f.Name()},
+				})
+
+				zones, err := List()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(zones[0].settings[constants.CfgOpennebulaSecret]).To(gomega.Equal("oneadmin:zone-a"))
+			})
+		})
+
+		ginkgo.Context("when zone has no name", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgZones, []map[string]interface{}{{"opennebula-endpoint": "http://a:2633/RPC2"}})
+
+				_, err := List()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.Context("when zone is configured more than once", func() {
+			ginkgo.It("should return error", func() {
+				viper.Set(constants.CfgZones, []map[string]interface{}{{"name": "zone-a"}, {"name": "zone-a"}})
+
+				_, err := List()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+
+	ginkgo.Describe("check zone", func() {
+		ginkgo.It("should return error when a required setting is not set", func() {
+			viper.Set(constants.CfgZones, []map[string]interface{}{{"name": "zone-a", "site-name": "site-a"}})
+
+			zones, err := List()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(zones[0].Check([]string{constants.CfgSiteName, constants.CfgOpennebulaTimeout})).To(
+				gomega.Succeed())
+			gomega.Expect(zones[0].Check([]string{constants.CfgOpennebulaEndpoint})).To(gomega.HaveOccurred())
+			gomega.Expect(zones[0].Check([]string{constants.CfgIdentifier})).To(gomega.Succeed())
+		})
+	})
+
+	ginkgo.Describe("run zone", func() {
+		ginkgo.It("should set settings of the zone to the configuration", func() {
+			viper.Set(constants.CfgZones, []map[string]interface{}{
+				{"name": "zone-a", "opennebula-endpoint": "http://a:2633/RPC2", "cloud-type": "cloud-a"},
+				{"name": "zone-b", "opennebula-endpoint": "http://b:2633/RPC2"},
+			})
+
+			zones, err := List()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			var endpoints, cloudTypes []string
+			for _, z := range zones {
+				z.Run(func() {
+					endpoints = append(endpoints, viper.GetString(constants.CfgOpennebulaEndpoint))
+					cloudTypes = append(cloudTypes, viper.GetString(constants.CfgNetworkCloudType))
+				})
+			}
+
+			gomega.Expect(endpoints).To(gomega.Equal([]string{"http://a:2633/RPC2", "http://b:2633/RPC2"}))
+			gomega.Expect(cloudTypes).To(gomega.Equal([]string{"cloud-a", ""}))
+		})
+
+		ginkgo.It("should set name of the zone being accounted", func() {
+			viper.Set(constants.CfgZones, []map[string]interface{}{{"name": "zone-a"}, {"name": "zone-b"}})
+
+			zones, err := List()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			var names []string
+			for _, z := range zones {
+				z.Run(func() {
+					names = append(names, Current())
+				})
+			}
+
+			gomega.Expect(names).To(gomega.Equal([]string{"zone-a", "zone-b"}))
+			gomega.Expect(Current()).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("keys set by zones", func() {
+		ginkgo.It("should contain OpenNebula settings, site names and cloud types", func() {
+			gomega.Expect(Sets(constants.CfgOpennebulaSecret)).To(gomega.BeTrue())
+			gomega.Expect(Sets(constants.CfgSite)).To(gomega.BeTrue())
+			gomega.Expect(Sets(constants.CfgEndpoint)).To(gomega.BeFalse())
+		})
+	})
+})